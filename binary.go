@@ -0,0 +1,133 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrUnsupportedType is returned when a value cannot be encoded/decoded
+var ErrUnsupportedType = errors.New("mtx: unsupported type")
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the number under the read lock
+// using a big-endian fixed-width representation (eg: 1 byte for int8, 4 bytes for float32).
+// int, uint and uintptr are always encoded on 8 bytes, so that the encoding does not depend on the platform.
+// Complex numbers are not supported.
+func (n Number[T]) MarshalBinary() ([]byte, error) {
+	rv := reflect.ValueOf(loadOrZero(n.Locker))
+	buf := make([]byte, binarySize(rv.Type()))
+	switch {
+	case rv.CanInt():
+		putUint(buf, uint64(rv.Int()))
+	case rv.CanUint():
+		putUint(buf, rv.Uint())
+	case rv.Kind() == reflect.Float32:
+		putUint(buf, uint64(math.Float32bits(float32(rv.Float()))))
+	case rv.Kind() == reflect.Float64:
+		putUint(buf, math.Float64bits(rv.Float()))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, rv.Type())
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding data produced by MarshalBinary
+// and storing the number under the write lock
+func (n *Number[T]) UnmarshalBinary(data []byte) error {
+	var out T
+	rv := reflect.ValueOf(&out).Elem()
+	size := binarySize(rv.Type())
+	if rv.CanComplex() {
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, rv.Type())
+	}
+	if len(data) != size {
+		return fmt.Errorf("mtx: invalid data length %d for %s, expected %d", len(data), rv.Type(), size)
+	}
+	u := getUint(data)
+	switch {
+	case rv.CanInt():
+		shift := 64 - 8*size // sign extension
+		v := int64(u<<shift) >> shift
+		if rv.OverflowInt(v) {
+			return fmt.Errorf("mtx: value %d overflows %s", v, rv.Type())
+		}
+		rv.SetInt(v)
+	case rv.CanUint():
+		if rv.OverflowUint(u) {
+			return fmt.Errorf("mtx: value %d overflows %s", u, rv.Type())
+		}
+		rv.SetUint(u)
+	case rv.Kind() == reflect.Float32:
+		rv.SetFloat(float64(math.Float32frombits(uint32(u))))
+	case rv.Kind() == reflect.Float64:
+		rv.SetFloat(math.Float64frombits(u))
+	}
+	if n.Locker == nil {
+		*n = NewNumber(out)
+		return nil
+	}
+	n.Store(out)
+	return nil
+}
+
+// returns the number of bytes used to encode a value of type t, platform-dependent sizes are always 8 bytes
+func binarySize(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Int, reflect.Uint, reflect.Uintptr:
+		return 8
+	}
+	return int(t.Size())
+}
+
+// writes the len(buf) least significant bytes of v in big-endian order
+func putUint(buf []byte, v uint64) {
+	switch len(buf) {
+	case 1:
+		buf[0] = byte(v)
+	case 2:
+		binary.BigEndian.PutUint16(buf, uint16(v))
+	case 4:
+		binary.BigEndian.PutUint32(buf, uint32(v))
+	case 8:
+		binary.BigEndian.PutUint64(buf, v)
+	}
+}
+
+// reads a big-endian unsigned integer of len(data) bytes
+func getUint(data []byte) uint64 {
+	switch len(data) {
+	case 1:
+		return uint64(data[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(data))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(data))
+	case 8:
+		return binary.BigEndian.Uint64(data)
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNumber_MarshalBinary_Int64(t *testing.T) {
+	n := NewNumber(int64(-1234567890123))
+	data, err := n.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, 8)
+	out := NewNumber(int64(0))
+	assert.NoError(t, out.UnmarshalBinary(data))
+	assert.Equal(t, int64(-1234567890123), out.Load())
+}
+
+func TestNumber_MarshalBinary_Float64(t *testing.T) {
+	n := NewRWNumber(3.14159)
+	data, err := n.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, 8)
+	var out Number[float64]
+	assert.NoError(t, out.UnmarshalBinary(data))
+	assert.Equal(t, 3.14159, out.Load())
+}
+
+func TestNumber_MarshalBinary_Widths(t *testing.T) {
+	i8 := NewNumber(int8(-5))
+	data, err := i8.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xfb}, data)
+	assert.NoError(t, i8.UnmarshalBinary(data))
+	assert.Equal(t, int8(-5), i8.Load())
+
+	u16 := NewNumber(uint16(0x0102))
+	data, err = u16.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02}, data)
+
+	f32 := NewNumber(float32(1.5))
+	data, err = f32.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, 4)
+	assert.NoError(t, f32.UnmarshalBinary(data))
+	assert.Equal(t, float32(1.5), f32.Load())
+
+	assert.Error(t, u16.UnmarshalBinary([]byte{1, 2, 3}))
+}
+
+func TestNumber_MarshalBinary_PlatformInt(t *testing.T) {
+	data, err := NewNumber(-2).MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}, data)
+	var i Number[int]
+	assert.NoError(t, i.UnmarshalBinary(data))
+	assert.Equal(t, -2, i.Load())
+
+	data, err = NewNumber(uintptr(7)).MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, 8)
+	assert.Error(t, i.UnmarshalBinary([]byte{0, 0, 0, 7}))
+
+	data, err = Number[uint]{}.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 8), data)
+}
+
+func TestNumber_MarshalBinary_Complex(t *testing.T) {
+	n := NewNumber(complex128(1 + 2i))
+	_, err := n.MarshalBinary()
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.ErrorIs(t, n.UnmarshalBinary(make([]byte, 16)), ErrUnsupportedType)
+}
@@ -0,0 +1,91 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+// BoundedSlice mutex protected slice which drops its oldest elements
+// when a write would grow it past its capacity.
+// The bound is enforced after every write made through the Slice methods and the package helpers,
+// writes made through Lock/GetPointer/Unlock are only trimmed by the next locked write.
+type BoundedSlice[T any] struct {
+	Slice[T]
+	capacity int
+}
+
+// Compile time checks to ensure types satisfies interfaces
+var _ ISlice[any] = (*BoundedSlice[any])(nil)
+var _ Locker[[]any] = (*boundedMtx[any])(nil)
+
+// NewBoundedSlice returns a new empty BoundedSlice with a sync.Mutex as backend.
+// A capacity <= 0 means the slice is unbounded.
+func NewBoundedSlice[T any](capacity int) BoundedSlice[T] {
+	return BoundedSlice[T]{Slice[T]{Locker: newBoundedMtxPtr(make([]T, 0, max(capacity, 0)), capacity)}, capacity}
+}
+
+// NewBoundedSlicePtr same as NewBoundedSlice, but as a pointer
+func NewBoundedSlicePtr[T any](capacity int) *BoundedSlice[T] {
+	return toPtr(NewBoundedSlice[T](capacity))
+}
+
+// Cap returns the maximum number of elements the slice can hold, 0 if unbounded.
+// It overrides Slice.Cap, which reports the capacity of the underlying array rather than the bound.
+func (s *BoundedSlice[T]) Cap() int { return max(s.capacity, 0) }
+
+// Unshift insert new element at beginning of the slice,
+// removing the last element of the slice if it exceeds its capacity
+func (s *BoundedSlice[T]) Unshift(el T) {
+	s.With(func(v *[]T) {
+		*v = append([]T{el}, *v...)
+		if s.capacity > 0 && len(*v) > s.capacity {
+			clear((*v)[s.capacity:])
+			*v = (*v)[:s.capacity]
+		}
+	})
+}
+
+//-----------------------------------------------------------------------------
+
+// bounded helper, drops the oldest elements of the slice after every write that grew it past capacity
+type boundedMtx[T any] struct {
+	*base[*Mutex, []T]
+	capacity int
+}
+
+// newBoundedMtxPtr creates a new boundedMtx
+func newBoundedMtxPtr[T any](v []T, capacity int) *boundedMtx[T] {
+	m := &boundedMtx[T]{base: newBase(&Mutex{}, v), capacity: capacity}
+	m.hook = m
+	return m
+}
+
+func (m *boundedMtx[T]) beforeWrite(*[]T) {}
+
+// afterWrite removes elements from the beginning of the slice if it exceeds its capacity,
+// even if the callback reported no change, since it may have modified the slice anyway
+func (m *boundedMtx[T]) afterWrite(v *[]T, _ bool) func() {
+	if m.capacity > 0 && len(*v) > m.capacity {
+		n := copy(*v, (*v)[len(*v)-m.capacity:])
+		clear((*v)[n:])
+		*v = (*v)[:n]
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBoundedSlice_Append(t *testing.T) {
+	s := NewBoundedSlice[int](3)
+	assert.Equal(t, 3, s.Cap())
+	assert.GreaterOrEqual(t, s.Slice.Cap(), 3)
+	s.Append(1, 2)
+	assert.Equal(t, []int{1, 2}, s.Load())
+	s.Append(3, 4)
+	assert.Equal(t, []int{2, 3, 4}, s.Load())
+	s.Append(5, 6, 7, 8, 9)
+	assert.Equal(t, []int{7, 8, 9}, s.Load())
+	assert.Equal(t, 3, s.Len())
+}
+
+func TestBoundedSlice_Unshift(t *testing.T) {
+	s := NewBoundedSlicePtr[int](2)
+	s.Unshift(1)
+	s.Unshift(2)
+	s.Unshift(3)
+	assert.Equal(t, []int{3, 2}, s.Load())
+}
+
+func TestBoundedSlice_Unbounded(t *testing.T) {
+	s := NewBoundedSlicePtr[int](0)
+	assert.Equal(t, 0, s.Cap())
+	s.Append(1, 2, 3, 4)
+	s.Unshift(0)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, s.Load())
+}
+
+func TestBoundedSlice_EveryWritePath(t *testing.T) {
+	full := func() *BoundedSlice[int] {
+		s := NewBoundedSlicePtr[int](3)
+		s.Append(1, 2, 3)
+		return s
+	}
+	writes := map[string]func(s *BoundedSlice[int]){
+		"Insert":            func(s *BoundedSlice[int]) { s.Insert(1, 9) },
+		"Resize":            func(s *BoundedSlice[int]) { s.Resize(5, 9) },
+		"Store":             func(s *BoundedSlice[int]) { s.Store([]int{5, 6, 7, 8, 9}) },
+		"Swap":              func(s *BoundedSlice[int]) { s.Swap([]int{5, 6, 7, 8, 9}) },
+		"SwapFunc":          func(s *BoundedSlice[int]) { s.SwapFunc(func(old []int) []int { return append(old, 9) }) },
+		"With":              func(s *BoundedSlice[int]) { s.With(func(v *[]int) { *v = append(*v, 9) }) },
+		"WithE":             func(s *BoundedSlice[int]) { _ = s.WithE(func(v *[]int) error { *v = append(*v, 9); return nil }) },
+		"WithRecover":       func(s *BoundedSlice[int]) { _ = s.WithRecover(func(v *[]int) { *v = append(*v, 9); panic("boom") }) },
+		"WithTimeout":       func(s *BoundedSlice[int]) { s.WithTimeout(time.Second, func(v *[]int) { *v = append(*v, 9) }) },
+		"Grow":              func(s *BoundedSlice[int]) { s.Grow(10) },
+		"Set":               func(s *BoundedSlice[int]) { s.Set(0, 9) },
+		"SliceInsertBefore": func(s *BoundedSlice[int]) { SliceInsertBefore(s, 2, 9) },
+		"SliceInsertAfter":  func(s *BoundedSlice[int]) { SliceInsertAfter(s, 2, 9) },
+	}
+	for name, write := range writes {
+		s := full()
+		write(s)
+		assert.LessOrEqual(t, s.Len(), 3, name)
+	}
+	s := full()
+	s.Insert(0, 0)
+	assert.Equal(t, []int{1, 2, 3}, s.Load(), "oldest elements are dropped")
+	s.Store([]int{5, 6, 7, 8, 9})
+	assert.Equal(t, []int{7, 8, 9}, s.Load())
+}
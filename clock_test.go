@@ -0,0 +1,59 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// manualClock is a Clock which only moves forward when advanced
+type manualClock struct{ now Mtx[time.Time] }
+
+func newManualClock(now time.Time) *manualClock { return &manualClock{NewMtx(now)} }
+
+func (c *manualClock) Now() time.Time { return c.now.Load() }
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.now.With(func(v *time.Time) { *v = v.Add(d) })
+}
+
+func TestClock_ManualExpiry(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := NewExpiringMtxWithClock(42, time.Hour, clock)
+	clock.Advance(time.Hour - time.Nanosecond)
+	assert.True(t, second(m.Load()))
+	clock.Advance(time.Nanosecond)
+	assert.False(t, second(m.Load()))
+	m.Store(43)
+	v, ok := m.Load()
+	assert.True(t, ok)
+	assert.Equal(t, 43, v)
+}
+
+func TestRealClock(t *testing.T) {
+	before := time.Now()
+	now := realClock{}.Now()
+	assert.False(t, now.Before(before))
+}
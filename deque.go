@@ -0,0 +1,125 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+// Deque mutex protected double-ended queue, backed by a ring buffer
+// so that pushing/popping at either end is O(1)
+type Deque[T any] struct{ m *mtx[ring[T]] }
+
+// NewDeque returns a new empty Deque with a sync.Mutex as backend
+func NewDeque[T any]() Deque[T] { return Deque[T]{newMtxPtr(ring[T]{})} }
+
+// NewDequePtr same as NewDeque, but as a pointer
+func NewDequePtr[T any]() *Deque[T] { return toPtr(NewDeque[T]()) }
+
+// PushFront inserts el at the front of the deque
+func (d *Deque[T]) PushFront(el T) { d.m.With(func(r *ring[T]) { r.pushFront(el) }) }
+
+// PushBack inserts el at the back of the deque
+func (d *Deque[T]) PushBack(el T) { d.m.With(func(r *ring[T]) { r.pushBack(el) }) }
+
+// PopFront removes and returns the first element, or false if the deque is empty
+func (d *Deque[T]) PopFront() (out T, ok bool) {
+	d.m.With(func(r *ring[T]) { out, ok = r.popFront() })
+	return
+}
+
+// PopBack removes and returns the last element, or false if the deque is empty
+func (d *Deque[T]) PopBack() (out T, ok bool) {
+	d.m.With(func(r *ring[T]) { out, ok = r.popBack() })
+	return
+}
+
+// Len returns the number of elements in the deque
+func (d *Deque[T]) Len() (out int) {
+	d.m.RWith(func(r ring[T]) { out = r.size })
+	return
+}
+
+// Each iterates each values of the deque, from front to back
+func (d *Deque[T]) Each(clb func(T)) {
+	d.m.RWith(func(r ring[T]) {
+		for i := 0; i < r.size; i++ {
+			clb(r.at(i))
+		}
+	})
+}
+
+//-----------------------------------------------------------------------------
+
+// ring is a growable circular buffer, it is not thread-safe on its own
+type ring[T any] struct {
+	buf  []T
+	head int // index of the first element in buf
+	size int // number of elements in buf
+}
+
+// at returns the i-th element, counting from the front
+func (r *ring[T]) at(i int) T { return r.buf[(r.head+i)%len(r.buf)] }
+
+// grow doubles the capacity of the buffer when it is full, unwrapping the elements
+func (r *ring[T]) grow() {
+	if r.size < len(r.buf) {
+		return
+	}
+	buf := make([]T, max(2*len(r.buf), 8))
+	for i := 0; i < r.size; i++ {
+		buf[i] = r.at(i)
+	}
+	r.buf, r.head = buf, 0
+}
+
+func (r *ring[T]) pushFront(el T) {
+	r.grow()
+	r.head = (r.head - 1 + len(r.buf)) % len(r.buf)
+	r.buf[r.head] = el
+	r.size++
+}
+
+func (r *ring[T]) pushBack(el T) {
+	r.grow()
+	r.buf[(r.head+r.size)%len(r.buf)] = el
+	r.size++
+}
+
+func (r *ring[T]) popFront() (out T, ok bool) {
+	if r.size == 0 {
+		return
+	}
+	var zero T
+	out, r.buf[r.head] = r.buf[r.head], zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
+	return out, true
+}
+
+func (r *ring[T]) popBack() (out T, ok bool) {
+	if r.size == 0 {
+		return
+	}
+	var zero T
+	i := (r.head + r.size - 1) % len(r.buf)
+	out, r.buf[i] = r.buf[i], zero
+	r.size--
+	return out, true
+}
@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func dequeValues[T any](d *Deque[T]) []T {
+	out := make([]T, 0)
+	d.Each(func(el T) { out = append(out, el) })
+	return out
+}
+
+func TestDeque(t *testing.T) {
+	d := NewDeque[int]()
+	assert.Equal(t, 0, d.Len())
+	d.PushBack(2)
+	d.PushBack(3)
+	d.PushFront(1)
+	assert.Equal(t, 3, d.Len())
+	assert.Equal(t, []int{1, 2, 3}, dequeValues(&d))
+	el, ok := d.PopFront()
+	assert.True(t, ok)
+	assert.Equal(t, 1, el)
+	el, ok = d.PopBack()
+	assert.True(t, ok)
+	assert.Equal(t, 3, el)
+	assert.Equal(t, []int{2}, dequeValues(&d))
+}
+
+func TestDeque_Empty(t *testing.T) {
+	d := NewDequePtr[string]()
+	el, ok := d.PopFront()
+	assert.False(t, ok)
+	assert.Equal(t, "", el)
+	el, ok = d.PopBack()
+	assert.False(t, ok)
+	assert.Equal(t, "", el)
+	d.PushFront("a")
+	_, _ = d.PopBack()
+	_, ok = d.PopFront()
+	assert.False(t, ok)
+	assert.Equal(t, 0, d.Len())
+}
+
+func TestDeque_Grow(t *testing.T) {
+	d := NewDequePtr[int]()
+	expected := make([]int, 0)
+	for i := 0; i < 20; i++ {
+		d.PushFront(-i)
+		d.PushBack(i)
+		expected = append([]int{-i}, append(expected, i)...)
+	}
+	assert.Equal(t, 40, d.Len())
+	assert.Equal(t, expected, dequeValues(d))
+	for i := 19; i >= 0; i-- {
+		assert.Equal(t, -i, first(d.PopFront()))
+		assert.Equal(t, i, first(d.PopBack()))
+	}
+	assert.Equal(t, 0, d.Len())
+}
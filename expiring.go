@@ -0,0 +1,64 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import "time"
+
+// ExpiringMtx mutex protected value which expires once "ttl" has elapsed since it was last stored
+type ExpiringMtx[T any] struct {
+	m     *mtx[expiringValue[T]]
+	ttl   time.Duration
+	clock Clock
+}
+
+// value and the time at which it was stored
+type expiringValue[T any] struct {
+	v     T
+	setAt time.Time
+}
+
+// NewExpiringMtx returns a new ExpiringMtx with a sync.Mutex as backend
+func NewExpiringMtx[T any](v T, ttl time.Duration) ExpiringMtx[T] {
+	return NewExpiringMtxWithClock(v, ttl, realClock{})
+}
+
+// NewExpiringMtxWithClock same as NewExpiringMtx, but uses "clock" to get the current time
+func NewExpiringMtxWithClock[T any](v T, ttl time.Duration, clock Clock) ExpiringMtx[T] {
+	return ExpiringMtx[T]{newMtxPtr(expiringValue[T]{v, clock.Now()}), ttl, clock}
+}
+
+// Load returns the value, and false if it has expired
+func (m *ExpiringMtx[T]) Load() (out T, ok bool) {
+	m.m.RWith(func(e expiringValue[T]) {
+		out, ok = e.v, m.clock.Now().Sub(e.setAt) < m.ttl
+	})
+	return
+}
+
+// Store a new value and reset the expiry
+func (m *ExpiringMtx[T]) Store(v T) {
+	m.m.With(func(e *expiringValue[T]) { *e = expiringValue[T]{v, m.clock.Now()} })
+}
+
+// TTL returns the duration after which a stored value expires
+func (m *ExpiringMtx[T]) TTL() time.Duration { return m.ttl }
@@ -0,0 +1,58 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExpiringMtx(t *testing.T) {
+	m := NewExpiringMtx("token", 20*time.Millisecond)
+	v, ok := m.Load()
+	assert.True(t, ok)
+	assert.Equal(t, "token", v)
+	time.Sleep(30 * time.Millisecond)
+	v, ok = m.Load()
+	assert.False(t, ok)
+	assert.Equal(t, "token", v)
+	m.Store("new token")
+	v, ok = m.Load()
+	assert.True(t, ok)
+	assert.Equal(t, "new token", v)
+}
+
+func TestExpiringMtx_Clock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewExpiringMtxWithClock("token", time.Minute, ClockFunc(func() time.Time { return now }))
+	assert.Equal(t, time.Minute, m.TTL())
+	now = now.Add(59 * time.Second)
+	assert.True(t, second(m.Load()))
+	now = now.Add(time.Second)
+	assert.False(t, second(m.Load()))
+	m.Store("token")
+	assert.True(t, second(m.Load()))
+}
+
+func second[T, U any](_ T, b U) U { return b }
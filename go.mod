@@ -1,6 +1,6 @@
 module github.com/alaingilbert/mtx
 
-go 1.21.4
+go 1.24
 
 require github.com/stretchr/testify v1.8.4
 
@@ -0,0 +1,99 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// GobEncode implements gob.GobEncoder, encoding the value under the read lock
+func (m Mtx[T]) GobEncode() ([]byte, error) { return gobEncode[T](m) }
+
+// GobDecode implements gob.GobDecoder, storing the decoded value under the write lock.
+// A zero Mtx is initialized with a sync.Mutex as backend.
+func (m *Mtx[T]) GobDecode(data []byte) error {
+	if m.Locker == nil {
+		*m = NewMtx(*new(T))
+	}
+	return gobDecode[T](m, data, func(v T) T { return v })
+}
+
+// GobEncode implements gob.GobEncoder, encoding the map under the read lock
+func (m Map[K, V]) GobEncode() ([]byte, error) { return gobEncode[map[K]V](m) }
+
+// GobDecode implements gob.GobDecoder, storing the decoded map under the write lock.
+// A zero Map is initialized with a sync.Mutex as backend.
+func (m *Map[K, V]) GobDecode(data []byte) error {
+	if m.Locker == nil {
+		*m = NewMap[K, V](nil)
+	}
+	return gobDecode[map[K]V](m, data, defaultMap[K, V])
+}
+
+// GobEncode implements gob.GobEncoder, encoding the slice under the read lock
+func (s Slice[T]) GobEncode() ([]byte, error) { return gobEncode[[]T](s) }
+
+// GobDecode implements gob.GobDecoder, storing the decoded slice under the write lock.
+// A zero Slice is initialized with a sync.Mutex as backend.
+func (s *Slice[T]) GobDecode(data []byte) error {
+	if s.Locker == nil {
+		*s = NewSlice[T](nil)
+	}
+	return gobDecode[[]T](s, data, defaultSlice[T])
+}
+
+// GobEncode implements gob.GobEncoder, encoding the number under the read lock
+func (n Number[T]) GobEncode() ([]byte, error) { return gobEncode[T](n) }
+
+// GobDecode implements gob.GobDecoder, storing the decoded number under the write lock.
+// A zero Number is initialized with a sync.Mutex as backend.
+func (n *Number[T]) GobDecode(data []byte) error {
+	if n.Locker == nil {
+		*n = NewNumber(*new(T))
+	}
+	return gobDecode[T](n, data, func(v T) T { return v })
+}
+
+// encodes the value of m under the read lock
+func gobEncode[T any](m Locker[T]) (out []byte, err error) {
+	err = m.RWithE(func(v T) error {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+			return err
+		}
+		out = buf.Bytes()
+		return nil
+	})
+	return
+}
+
+// decodes data, then stores normalize(decoded) in m under the write lock
+func gobDecode[T any](m Locker[T], data []byte, normalize func(T) T) error {
+	var v T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		return err
+	}
+	m.Store(normalize(v))
+	return nil
+}
@@ -0,0 +1,71 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGob_Map(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 2})
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(m))
+	decoded := NewMap[string, int](nil)
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, decoded.Load())
+}
+
+func TestGob_Struct(t *testing.T) {
+	type state struct {
+		Name    Mtx[string]
+		Counter Number[int64]
+		Items   Slice[string]
+		Scores  Map[string, int]
+		Empty   Slice[int]
+	}
+	in := state{
+		Name:    NewMtx("foo"),
+		Counter: NewRWNumber(int64(42)),
+		Items:   NewSlice([]string{"a", "b"}),
+		Scores:  NewRWMap(map[string]int{"x": 1}),
+		Empty:   NewSlice[int](nil),
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(in))
+	var out state
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&out))
+	assert.Equal(t, "foo", out.Name.Load())
+	assert.Equal(t, int64(42), out.Counter.Load())
+	assert.Equal(t, []string{"a", "b"}, out.Items.Load())
+	assert.Equal(t, map[string]int{"x": 1}, out.Scores.Load())
+	assert.Equal(t, []int{}, out.Empty.Load())
+}
+
+func TestGob_DecodeError(t *testing.T) {
+	m := NewMtx(1)
+	assert.Error(t, m.GobDecode([]byte("invalid")))
+	assert.Equal(t, 1, m.Load())
+}
@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import "reflect"
+
+// MetricSample is a point-in-time measurement of a mtx value
+type MetricSample struct {
+	Name  string
+	Value float64
+}
+
+// Collector is the interface implemented by types that can report a MetricSample (Number/Map/Slice)
+type Collector interface {
+	Collect() MetricSample
+}
+
+// Compile time checks to ensure types satisfies interfaces
+var _ Collector = (*Number[int])(nil)
+var _ Collector = (*Map[int, int])(nil)
+var _ Collector = (*Slice[int])(nil)
+
+// CollectAll returns a sample for each of the given collectors
+func CollectAll(collectors ...Collector) []MetricSample {
+	out := make([]MetricSample, 0, len(collectors))
+	for _, c := range collectors {
+		out = append(out, c.Collect())
+	}
+	return out
+}
+
+// Collect returns a sample of the current value of the number
+// Complex numbers are reported using their real part
+func (n *Number[T]) Collect() MetricSample {
+	return MetricSample{Name: n.name, Value: toFloat64(n.Load())}
+}
+
+// Collect returns a sample of the current length of the map
+func (m *Map[K, V]) Collect() MetricSample {
+	return MetricSample{Name: m.name, Value: float64(m.Len())}
+}
+
+// Collect returns a sample of the current length of the slice
+func (s *Slice[T]) Collect() MetricSample {
+	return MetricSample{Name: s.name, Value: float64(s.Len())}
+}
+
+// converts any number to a float64, using the real part for complex numbers
+func toFloat64[T INumber](v T) float64 {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return float64(rv.Int())
+	case rv.CanUint():
+		return float64(rv.Uint())
+	case rv.CanFloat():
+		return rv.Float()
+	case rv.CanComplex():
+		return real(rv.Complex())
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollectAll(t *testing.T) {
+	n := NewNumberNamed("requests_total", uint64(0))
+	n.Add(3)
+	m := NewMapNamed("sessions", map[string]int{"a": 1, "b": 2})
+	s := NewSliceNamed("queue", []int{1, 2, 3, 4})
+	c := NewNumberNamed("signal", complex(1.5, 2))
+	samples := CollectAll(&n, &m, &s, &c)
+	assert.Equal(t, []MetricSample{
+		{Name: "requests_total", Value: 3},
+		{Name: "sessions", Value: 2},
+		{Name: "queue", Value: 4},
+		{Name: "signal", Value: 1.5},
+	}, samples)
+}
+
+func TestNumber_Collect(t *testing.T) {
+	n := NewRWNumber(-2.5)
+	assert.Equal(t, MetricSample{Value: -2.5}, n.Collect())
+}
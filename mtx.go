@@ -20,9 +20,26 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
+// Package mtx provides generic mutex protected values: Mtx, Map, Slice and Number.
+//
+// Mtx, Map, Slice and Number implement IsZero, so that encoding/json omits them when tagged with "omitzero"
+// and the protected value is the zero value (nil or empty for Map and Slice).
+// "omitempty" has no effect on them, as they are structs, not maps, slices or numbers.
 package mtx
 
-import "sync"
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
 
 // Mutex alias type
 type Mutex = sync.Mutex
@@ -34,6 +51,103 @@ func toPtr[T any](v T) *T { return &v }
 
 func first[T any](a T, _ ...any) T { return a }
 
+// returns the address of p, used to acquire multiple locks in a consistent order
+func addrOf[T any](p *T) uintptr { return uintptr(unsafe.Pointer(p)) }
+
+// formats any number using strconv, based on its concrete kind
+func formatNumber[T INumber](v T) string {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return strconv.FormatInt(rv.Int(), 10)
+	case rv.CanUint():
+		return strconv.FormatUint(rv.Uint(), 10)
+	case rv.CanFloat():
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits())
+	case rv.CanComplex():
+		return strconv.FormatComplex(rv.Complex(), 'g', -1, rv.Type().Bits())
+	}
+	return ""
+}
+
+// returns the value protected by l, or the zero value of T if l is nil (zero value wrapper)
+func loadOrZero[T any](l Locker[T]) (out T) {
+	if l != nil {
+		out = l.Load()
+	}
+	return
+}
+
+// sets the value protected by l to the result of f applied to the current value, and returns the old value
+func swapFunc[T any](l Locker[T], f func(old T) T) (old T) {
+	l.With(func(v *T) {
+		old = *v
+		*v = f(old)
+	})
+	return
+}
+
+// returns l as I, panics with a clear message if l is not one of this package's backends
+func backend[I any, T any](l Locker[T]) I {
+	b, ok := l.(I)
+	if !ok {
+		panic(fmt.Sprintf("mtx: unsupported backend %T", l))
+	}
+	return b
+}
+
+// same as l.With, but recovers from a panic in clb and returns it as an error, see Mtx.WithRecover
+func withRecover[T any](l Locker[T], clb func(v *T)) error {
+	return l.WithE(func(v *T) error { return recoverClb(v, clb) })
+}
+
+// same as l.With, but gives up if the lock cannot be acquired within "d", see Mtx.WithTimeout
+func withTimeout[T any](l Locker[T], d time.Duration, clb func(v *T)) bool {
+	return backend[interface {
+		WithTimeout(time.Duration, func(*T)) bool
+	}](l).WithTimeout(d, clb)
+}
+
+// returns a Locker for the read lock of l, see Mtx.RLocker
+func rLocker[T any](l Locker[T]) sync.Locker {
+	return backend[interface{ RLocker() sync.Locker }](l).RLocker()
+}
+
+// prefixes s with "name=", unless name is empty
+func withName(name, s string) string {
+	if name == "" {
+		return s
+	}
+	return name + "=" + s
+}
+
+// formats the wrapper w holding the value v as Go syntax, including the name unless it is empty
+func goStringWithName(w any, name string, v any) string {
+	if name == "" {
+		return fmt.Sprintf("%T{%#v}", w, v)
+	}
+	return fmt.Sprintf("%T{Name:%q, Value:%#v}", w, name, v)
+}
+
+// turns a "less" function into a "cmp" function, as expected by slices.SortFunc
+func lessToCmp[T any](less func(a, b T) bool) func(a, b T) int {
+	return func(a, b T) int {
+		if less(a, b) {
+			return -1
+		} else if less(b, a) {
+			return 1
+		}
+		return 0
+	}
+}
+
+// returns a new mapState with its counter initialized to n
+func newMapState[K comparable, V any](n int) *mapState[K, V] {
+	st := new(mapState[K, V])
+	st.count.Store(int64(n))
+	return st
+}
+
 // returns a default empty map if v is nil
 func defaultMap[K comparable, V any](v map[K]V) map[K]V {
 	if v == nil {
@@ -71,18 +185,46 @@ type Locker[T any] interface {
 // IMap is the interface that Map implements
 type IMap[K comparable, V any] interface {
 	Locker[map[K]V]
+	ApproxLen() int
 	Clear()
 	Clone() (out map[K]V)
+	ComputeIfAbsent(k K, f func(K) V) (out V)
+	ComputeIfPresent(k K, f func(K, V) (V, bool)) (out V, ok bool)
 	ContainsKey(k K) (found bool)
 	Delete(k K)
+	DeleteFunc(pred func(K, V) bool) (out int)
+	Drain() (out map[K]V)
 	Each(clb func(K, V))
+	EachParallel(workers int, clb func(K, V))
+	EachUntil(clb func(K, V) bool)
+	Entries() (out []Pair[K, V])
 	Get(k K) (out V, ok bool)
 	GetKeyValue(k K) (key K, value V, ok bool)
+	GetMany(keys []K) (out map[K]V)
 	Insert(k K, v V)
+	InsertMany(entries map[K]V)
+	InsertPairs(pairs ...Pair[K, V])
 	IsEmpty() bool
+	IsZero() bool
+	Iter() iter.Seq2[K, V]
+	IterSnapshot() iter.Seq2[K, V]
 	Keys() (out []K)
+	KeysFunc(less func(a, b K) bool) (out []K)
 	Len() (out int)
+	LoadAndDelete(k K) (value V, loaded bool)
+	LoadOrStore(k K, v V) (actual V, loaded bool)
+	Merge(other map[K]V)
+	MergeFunc(other map[K]V, resolve func(k K, existing, incoming V) V)
+	Name() string
+	Pop() (k K, v V, ok bool)
+	Rebuild(f func(old map[K]V) map[K]V)
 	Remove(k K) (out V, ok bool)
+	ReplaceAll(f func(K, V) V)
+	SetWAL(w func(op string, k K, v V))
+	SortedEntriesFunc(less func(a, b Pair[K, V]) bool) (out []Pair[K, V])
+	Transaction(f func(raw map[K]V))
+	TxE(f func(m map[K]V) error) error
+	Update(k K, f func(old V, existed bool) V)
 	Values() (out []V)
 }
 
@@ -90,18 +232,51 @@ type IMap[K comparable, V any] interface {
 type ISlice[T any] interface {
 	Locker[[]T]
 	Append(els ...T)
+	Cap() (out int)
 	Clear()
 	Clone() (out []T)
+	CopyInto(dst []T) (n int)
+	Drain() (out []T)
 	Each(clb func(T))
+	EachUntil(clb func(T) bool)
 	Filter(func(T) bool) []T
+	FilterInto(dst []T, keep func(el T) bool) (out []T)
+	First() (out T, ok bool)
+	FirstWhere(pred func(T) bool) (out T, ok bool)
 	Get(i int) (out T)
+	Grow(n int)
 	Insert(i int, el T)
 	IsEmpty() bool
+	IsZero() bool
+	Iter() iter.Seq[T]
+	IterSnapshot() iter.Seq[T]
+	Last() (out T, ok bool)
 	Len() (out int)
+	Move(from, to int)
+	Name() string
+	Peek() (out T, ok bool)
 	Pop() (out T)
+	PopN(n int) (out []T)
+	PopOk() (out T, ok bool)
 	Remove(i int) (out T)
+	Resize(n int, fill T)
+	Retain(keep func(el T) bool)
+	Rotate(n int)
+	Set(i int, el T)
 	Shift() (out T)
+	ShiftN(n int) (out []T)
+	ShiftOk() (out T, ok bool)
+	SortFunc(cmp func(a, b T) int)
+	SwapAt(i, j int)
+	SwapContents(other *Slice[T])
+	Truncate(n int)
 	Unshift(el T)
+	UpdateEach(f func(i int, el T) T)
+}
+
+// Sized is the interface implemented by collections that have a length (Map/Slice)
+type Sized interface {
+	Len() int
 }
 
 // INumber all numbers
@@ -112,24 +287,69 @@ type INumber interface {
 		~complex64 | ~complex128
 }
 
+// IOrderedNumber all numbers that can be ordered, that is all numbers except complex ones
+type IOrderedNumber interface {
+	~float32 | ~float64 |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
 //-----------------------------------------------------------------------------
 // Types
 
+// Pair a key/value pair
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
 // Mtx mutex protected value
-type Mtx[T any] struct{ Locker[T] }
+type Mtx[T any] struct {
+	Locker[T]
+	name string
+}
 
 // Map mutex protected map
-type Map[K comparable, V any] struct{ Locker[map[K]V] }
+type Map[K comparable, V any] struct {
+	Locker[map[K]V]
+	name  string
+	state *mapState[K, V] // shared by all copies of the Map
+}
+
+// mapState state of a Map shared by all its copies, so that copies made before SetWAL also see the hook
+type mapState[K comparable, V any] struct {
+	count atomic.Int64              // length of the map as of the last write, see ApproxLen
+	wal   func(op string, k K, v V) // guarded by the Map lock, see SetWAL
+}
 
 // Slice mutex protected slice
-type Slice[V any] struct{ Locker[[]V] }
+type Slice[V any] struct {
+	Locker[[]V]
+	name string
+}
 
 // Number mutex protected number
-type Number[T INumber] struct{ Locker[T] }
+type Number[T INumber] struct {
+	Locker[T]
+	name string
+}
 
 type base[M sync.Locker, T any] struct {
-	m M
-	v T
+	m        M
+	v        T
+	watchers []chan T     // guarded by m
+	cond     *sync.Cond   // guarded by m, created on first use
+	hook     writeHook[T] // optional, set by backends that need to observe writes
+}
+
+// writeHook lets a backend observe the writes made through the base methods (WithE, withIf, WithTimeout...),
+// instead of re-implementing all of them
+type writeHook[T any] interface {
+	// beforeWrite is called with the lock held, before the callback
+	beforeWrite(v *T)
+	// afterWrite is called with the lock held, after the callback, changed is false if it reported no change.
+	// The returned function, if not nil, is called once the lock has been released.
+	afterWrite(v *T, changed bool) func()
 }
 
 // Compile time checks to ensure types satisfies interfaces
@@ -137,34 +357,220 @@ var _ Locker[any] = (*Mtx[any])(nil)
 var _ Locker[int] = (*Number[int])(nil)
 var _ IMap[int, int] = (*Map[int, int])(nil)
 var _ ISlice[any] = (*Slice[any])(nil)
+var _ Sized = (*Map[int, int])(nil)
+var _ Sized = (*Slice[any])(nil)
 var _ Locker[any] = (*base[sync.Locker, any])(nil)
+var _ Locker[any] = (*spinMtx[any])(nil)
+var _ Locker[any] = (*cowMtx[any])(nil)
+var _ Locker[any] = (*observableMtx[any])(nil)
+var _ Locker[any] = (*lazyMtx[any])(nil)
+var _ Locker[any] = (*debugMtx[any])(nil)
+var _ Locker[any] = (*dirtyMtx[any])(nil)
+var _ Locker[any] = (*versionedMtx[any])(nil)
+
+//-----------------------------------------------------------------------------
+// Functions
+
+// Total returns the sum of the lengths of all items
+func Total(items ...Sized) (out int) {
+	for _, item := range items {
+		out += item.Len()
+	}
+	return
+}
+
+// Equal returns true if the values protected by a and b are equal
+func Equal[T comparable](a, b Locker[T]) bool {
+	return EqualFunc(a, b, func(x, y T) bool { return x == y })
+}
+
+// EqualFunc returns true if eq reports the values protected by a and b as equal.
+// Both read locks are acquired in address order to prevent deadlocks.
+func EqualFunc[T any](a, b Locker[T], eq func(T, T) bool) bool {
+	pa, pb := a.GetPointer(), b.GetPointer()
+	if pa == pb {
+		a.RLock()
+		defer a.RUnlock()
+		return eq(*pa, *pa)
+	}
+	lo, hi := a, b
+	if addrOf(pa) > addrOf(pb) {
+		lo, hi = b, a
+	}
+	lo.RLock()
+	defer lo.RUnlock()
+	hi.RLock()
+	defer hi.RUnlock()
+	return eq(*pa, *pb)
+}
+
+// StoreIfChanged stores v only if it differs from the current value, and returns whether it was stored.
+// When nothing changed, watchers (see Mtx.Watch) and WaitFor callers are not notified.
+// Panics if m was not created by one of this package's constructors.
+func StoreIfChanged[T comparable](m *Mtx[T], v T) bool {
+	return backend[interface{ withIf(func(*T) bool) bool }](m.Locker).withIf(func(cur *T) bool {
+		if *cur == v {
+			return false
+		}
+		*cur = v
+		return true
+	})
+}
+
+// RWith2 runs f with the values protected by a and b, under both read locks.
+// Locks are acquired in address order to prevent deadlocks, a and b can be the same locker.
+func RWith2[A, B any](a Locker[A], b Locker[B], f func(A, B)) {
+	pa, pb := a.GetPointer(), b.GetPointer()
+	withOrdered(func() { f(*pa, *pb) },
+		lockScope{addrOf(pa), func(inner func()) { a.RWith(func(A) { inner() }) }},
+		lockScope{addrOf(pb), func(inner func()) { b.RWith(func(B) { inner() }) }})
+}
+
+// With2 runs f with pointers to the values protected by a and b, under both write locks.
+// Locks are acquired in address order to prevent deadlocks, a and b can be the same locker.
+func With2[A, B any](a Locker[A], b Locker[B], f func(*A, *B)) {
+	pa, pb := a.GetPointer(), b.GetPointer()
+	withOrdered(func() { f(pa, pb) },
+		lockScope{addrOf(pa), func(inner func()) { a.With(func(*A) { inner() }) }},
+		lockScope{addrOf(pb), func(inner func()) { b.With(func(*B) { inner() }) }})
+}
+
+// With3 same as With2, for three lockers
+func With3[A, B, C any](a Locker[A], b Locker[B], c Locker[C], f func(*A, *B, *C)) {
+	pa, pb, pc := a.GetPointer(), b.GetPointer(), c.GetPointer()
+	withOrdered(func() { f(pa, pb, pc) },
+		lockScope{addrOf(pa), func(inner func()) { a.With(func(*A) { inner() }) }},
+		lockScope{addrOf(pb), func(inner func()) { b.With(func(*B) { inner() }) }},
+		lockScope{addrOf(pc), func(inner func()) { c.With(func(*C) { inner() }) }})
+}
+
+// lockScope a locked scope, identified by the address of the protected value
+type lockScope struct {
+	addr uintptr
+	with func(inner func()) // runs inner while holding the lock
+}
+
+// withOrdered nests the scopes in address order and runs inner once all of them are entered.
+// Scopes sharing the same address are entered only once.
+func withOrdered(inner func(), scopes ...lockScope) {
+	slices.SortFunc(scopes, func(a, b lockScope) int { return cmp.Compare(a.addr, b.addr) })
+	scopes = slices.CompactFunc(scopes, func(a, b lockScope) bool { return a.addr == b.addr })
+	for i := len(scopes) - 1; i >= 0; i-- {
+		next, scope := inner, scopes[i]
+		inner = func() { scope.with(next) }
+	}
+	inner()
+}
+
+// LockAll locks all the lockers in address order to prevent deadlocks,
+// and returns a function that unlocks them in reverse order.
+// Lockers must be pointers (eg: *sync.Mutex, *Mtx[T], *Map[K, V]), duplicates are locked only once.
+// Lockers of this package are ordered by the address of their protected value, like With2/With3,
+// so that copies of the same Mtx are recognized as duplicates.
+func LockAll(lockers ...sync.Locker) (unlock func()) {
+	type keyed struct {
+		addr uintptr
+		l    sync.Locker
+	}
+	sorted := make([]keyed, 0, len(lockers))
+	for _, l := range lockers {
+		if reflect.ValueOf(l).Kind() != reflect.Pointer {
+			panic(fmt.Sprintf("mtx: LockAll requires pointer lockers, got %T", l))
+		}
+		sorted = append(sorted, keyed{lockAddr(l), l})
+	}
+	slices.SortFunc(sorted, func(a, b keyed) int { return cmp.Compare(a.addr, b.addr) })
+	sorted = slices.CompactFunc(sorted, func(a, b keyed) bool { return a.addr == b.addr })
+	for _, k := range sorted {
+		k.l.Lock()
+	}
+	return func() {
+		for i := len(sorted) - 1; i >= 0; i-- {
+			sorted[i].l.Unlock()
+		}
+	}
+}
+
+// lockAddr returns the address used to order l: the address of its protected value if it has
+// a GetPointer method (as used by With2/With3), the address of l itself otherwise
+func lockAddr(l sync.Locker) uintptr {
+	rv := reflect.ValueOf(l)
+	if gp := rv.MethodByName("GetPointer"); gp.IsValid() {
+		if t := gp.Type(); t.NumIn() == 0 && t.NumOut() == 1 && t.Out(0).Kind() == reflect.Pointer {
+			return gp.Call(nil)[0].Pointer()
+		}
+	}
+	return rv.Pointer()
+}
+
+// Project returns the result of f applied to the value protected by m, under the read lock
+func Project[M Locker[T], T, R any](m M, f func(T) R) (out R) {
+	m.RWith(func(v T) { out = f(v) })
+	return
+}
 
 //-----------------------------------------------------------------------------
 // Constructors
 
 // NewMtx returns a new Mtx with a sync.Mutex as backend
-func NewMtx[T any](v T) Mtx[T] { return Mtx[T]{newMtxPtr(v)} }
+func NewMtx[T any](v T) Mtx[T] { return Mtx[T]{Locker: newMtxPtr(v)} }
 
 // NewRWMtx returns a new Mtx with a sync.RWMutex as backend
-func NewRWMtx[T any](v T) Mtx[T] { return Mtx[T]{newRWMtxPtr(v)} }
+func NewRWMtx[T any](v T) Mtx[T] { return Mtx[T]{Locker: newRWMtxPtr(v)} }
+
+// NewSpinMtx returns a new Mtx with a spin-then-block sync.Mutex as backend.
+// It can reduce scheduler churn for very short critical sections under high contention.
+func NewSpinMtx[T any](v T) Mtx[T] { return Mtx[T]{Locker: newSpinMtxPtr(v)} }
+
+// NewCOWMtx returns a new Mtx with a copy-on-write sync.Mutex as backend.
+// Every write publishes a copy of the value, which makes LoadShared lock-free.
+// The copy is shallow, reference types (maps, slices, pointers) inside T are still shared.
+func NewCOWMtx[T any](v T) Mtx[T] { return Mtx[T]{Locker: newCOWMtxPtr(v)} }
+
+// NewLazyMtx returns a new Mtx with a sync.Mutex as backend, whose value is initialized by calling init,
+// under the lock, the first time it is accessed. init is called exactly once and must not use the Mtx.
+func NewLazyMtx[T any](init func() T) Mtx[T] { return Mtx[T]{Locker: newLazyMtxPtr(init)} }
+
+// NewMtxDebug returns a new Mtx with a sync.Mutex as backend, which calls report (after unlocking)
+// every time the write lock was held for longer than threshold. Read locks are not measured.
+func NewMtxDebug[T any](v T, threshold time.Duration, report func(held time.Duration)) Mtx[T] {
+	return Mtx[T]{Locker: newDebugMtxPtr(v, threshold, report)}
+}
+
+// NewDirtyMtx returns a new Mtx with a sync.Mutex as backend, which records whether the value
+// was written (Store/Swap/With) since the last call to ClearDirty, see IsDirty.
+// Writes made through Lock/GetPointer/Unlock, and WithE callbacks returning an error, are not recorded.
+func NewDirtyMtx[T any](v T) Mtx[T] { return Mtx[T]{Locker: newDirtyMtxPtr(v)} }
+
+// NewVersionedMtx returns a new Mtx with a sync.Mutex as backend, which counts the writes (Store/Swap/With)
+// made to the value, see Version and CompareVersionAndSwap.
+// Writes made through Lock/GetPointer/Unlock, and WithE callbacks returning an error, are not counted.
+func NewVersionedMtx[T any](v T) Mtx[T] { return Mtx[T]{Locker: newVersionedMtxPtr(v)} }
+
+// NewNamedMtx returns a new Mtx with a sync.Mutex as backend, and a name used for diagnostics
+func NewNamedMtx[T any](name string, v T) Mtx[T] { return Mtx[T]{Locker: newMtxPtr(v), name: name} }
 
 // NewNumber returns a new Number with a sync.Mutex as backend
-func NewNumber[T INumber](v T) Number[T] { return Number[T]{newMtxPtr(v)} }
+func NewNumber[T INumber](v T) Number[T] { return Number[T]{Locker: newMtxPtr(v)} }
 
 // NewRWNumber returns a new Number with a sync.RWMutex as backend
-func NewRWNumber[T INumber](v T) Number[T] { return Number[T]{newRWMtxPtr(v)} }
+func NewRWNumber[T INumber](v T) Number[T] { return Number[T]{Locker: newRWMtxPtr(v)} }
 
 // NewMap returns a new Map with a sync.Mutex as backend
-func NewMap[K comparable, V any](v map[K]V) Map[K, V] { return Map[K, V]{newMtxPtr(defaultMap(v))} }
+func NewMap[K comparable, V any](v map[K]V) Map[K, V] {
+	return Map[K, V]{Locker: newMtxPtr(defaultMap(v)), state: newMapState[K, V](len(v))}
+}
 
 // NewRWMap returns a new Map with a sync.RWMutex as backend
-func NewRWMap[K comparable, V any](v map[K]V) Map[K, V] { return Map[K, V]{newRWMtxPtr(defaultMap(v))} }
+func NewRWMap[K comparable, V any](v map[K]V) Map[K, V] {
+	return Map[K, V]{Locker: newRWMtxPtr(defaultMap(v)), state: newMapState[K, V](len(v))}
+}
 
 // NewSlice returns a new Slice with a sync.Mutex as backend
-func NewSlice[T any](v []T) Slice[T] { return Slice[T]{newMtxPtr(defaultSlice(v))} }
+func NewSlice[T any](v []T) Slice[T] { return Slice[T]{Locker: newMtxPtr(defaultSlice(v))} }
 
 // NewRWSlice returns a new Slice with a sync.RWMutex as backend
-func NewRWSlice[T any](v []T) Slice[T] { return Slice[T]{newRWMtxPtr(defaultSlice(v))} }
+func NewRWSlice[T any](v []T) Slice[T] { return Slice[T]{Locker: newRWMtxPtr(defaultSlice(v))} }
 
 // NewMtxPtr same as NewMtx, but as a pointer
 func NewMtxPtr[T any](v T) *Mtx[T] { return toPtr(NewMtx(v)) }
@@ -172,6 +578,15 @@ func NewMtxPtr[T any](v T) *Mtx[T] { return toPtr(NewMtx(v)) }
 // NewRWMtxPtr same as Mtx, but as a pointer
 func NewRWMtxPtr[T any](v T) *Mtx[T] { return toPtr(NewRWMtx(v)) }
 
+// NewSpinMtxPtr same as NewSpinMtx, but as a pointer
+func NewSpinMtxPtr[T any](v T) *Mtx[T] { return toPtr(NewSpinMtx(v)) }
+
+// NewCOWMtxPtr same as NewCOWMtx, but as a pointer
+func NewCOWMtxPtr[T any](v T) *Mtx[T] { return toPtr(NewCOWMtx(v)) }
+
+// NewVersionedMtxPtr same as NewVersionedMtx, but as a pointer
+func NewVersionedMtxPtr[T any](v T) *Mtx[T] { return toPtr(NewVersionedMtx(v)) }
+
 // NewNumberPtr same as NewNumber, but as a pointer
 func NewNumberPtr[T INumber](v T) *Number[T] { return toPtr(NewNumber(v)) }
 
@@ -190,10 +605,33 @@ func NewSlicePtr[T any](v []T) *Slice[T] { return toPtr(NewSlice(v)) }
 // NewRWSlicePtr same as NewRWSlice, but as a pointer
 func NewRWSlicePtr[T any](v []T) *Slice[T] { return toPtr(NewRWSlice(v)) }
 
+// NewNumberNamed same as NewNumber, but with a name used when collecting metrics
+func NewNumberNamed[T INumber](name string, v T) Number[T] {
+	return Number[T]{Locker: newMtxPtr(v), name: name}
+}
+
+// NewObservableNumber returns a new Number with a sync.Mutex as backend,
+// onChange is called with the old and new values after every Add/Sub/Store/Swap/With.
+// onChange is called once the lock has been released, so it can safely use the number.
+// It is not called when a WithE callback returns an error.
+func NewObservableNumber[T INumber](v T, onChange func(old, new T)) Number[T] {
+	return Number[T]{Locker: newObservableMtxPtr(v, onChange)}
+}
+
+// NewMapNamed same as NewMap, but with a name used when collecting metrics
+func NewMapNamed[K comparable, V any](name string, v map[K]V) Map[K, V] {
+	return Map[K, V]{Locker: newMtxPtr(defaultMap(v)), name: name, state: newMapState[K, V](len(v))}
+}
+
+// NewSliceNamed same as NewSlice, but with a name used when collecting metrics
+func NewSliceNamed[T any](name string, v []T) Slice[T] {
+	return Slice[T]{Locker: newMtxPtr(defaultSlice(v)), name: name}
+}
+
 //-----------------------------------------------------------------------------
 // Base implementation
 
-func newBase[M sync.Locker, T any](m M, v T) *base[M, T] { return &base[M, T]{m, v} }
+func newBase[M sync.Locker, T any](m M, v T) *base[M, T] { return &base[M, T]{m: m, v: v} }
 
 // Lock exposes the underlying sync.Mutex Lock function
 func (m *base[M, T]) Lock() { m.m.Lock() }
@@ -207,15 +645,22 @@ func (m *base[M, T]) RLock() { m.Lock() }
 // RUnlock is a default implementation of RUnlock to satisfy Locker interface
 func (m *base[M, T]) RUnlock() { m.Unlock() }
 
+// RLocker is a default implementation of RLocker used by Mtx.RLocker,
+// it returns the underlying mutex since it has no read lock
+func (m *base[M, T]) RLocker() sync.Locker { return m.m }
+
 // GetPointer returns a pointer to the protected value
 // WARNING: the caller must make sure the code that uses the returned pointer is thread-safe
 func (m *base[M, T]) GetPointer() *T { return &m.v }
 
-// WithE provide a callback scope where the wrapped value can be safely used
-func (m *base[M, T]) WithE(clb func(v *T) error) error {
-	m.Lock()
-	defer m.Unlock()
-	return clb(&m.v)
+// WithE provide a callback scope where the wrapped value can be safely used.
+// Watchers are notified only if clb returns nil.
+func (m *base[M, T]) WithE(clb func(v *T) error) (err error) {
+	m.write(nil, func(v *T) bool {
+		err = clb(v)
+		return err == nil
+	})
+	return
 }
 
 // With same as WithE but do return an error
@@ -228,9 +673,9 @@ func (m *base[M, T]) With(clb func(v *T)) {
 
 // RWithE provide a callback scope where the wrapped value can be safely used for Read only purposes
 func (m *base[M, T]) RWithE(clb func(v T) error) error {
-	return m.WithE(func(v *T) error {
-		return clb(*v)
-	})
+	m.RLock()
+	defer m.RUnlock()
+	return clb(m.v)
 }
 
 // RWith same as RWithE but do not return an error
@@ -261,6 +706,142 @@ func (m *base[M, T]) Swap(newVal T) (old T) {
 	return
 }
 
+// withIf same as With, but watchers are notified only if clb returns true
+func (m *base[M, T]) withIf(clb func(v *T) bool) (changed bool) {
+	_, changed = m.write(nil, clb)
+	return
+}
+
+// write runs clb with the lock held, acquired by calling acquire, or by blocking on m.m.Lock if acquire is nil.
+// Watchers are notified if clb reports a change, and the write hook, if any, is called around clb.
+// Returns whether the lock was acquired, and whether clb reported a change.
+func (m *base[M, T]) write(acquire func() bool, clb func(v *T) bool) (acquired, changed bool) {
+	if acquire == nil {
+		m.m.Lock()
+	} else if !acquire() {
+		return false, false
+	}
+	var afterUnlock func()
+	func() {
+		defer m.m.Unlock()
+		if m.hook != nil {
+			m.hook.beforeWrite(&m.v)
+			defer func() { afterUnlock = m.hook.afterWrite(&m.v, changed) }()
+		}
+		if changed = clb(&m.v); changed {
+			m.notify()
+		}
+	}()
+	if afterUnlock != nil {
+		afterUnlock()
+	}
+	return true, changed
+}
+
+// recoverClb calls clb, and converts a panic into an error, wrapping it if it is one
+func recoverClb[T any](v *T, clb func(v *T)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = fmt.Errorf("mtx: recovered from panic: %w", e)
+			} else {
+				err = fmt.Errorf("mtx: recovered from panic: %v", r)
+			}
+		}
+	}()
+	clb(v)
+	return nil
+}
+
+// WithTimeout same as With, but gives up if the lock cannot be acquired within "d".
+// Returns true if the callback was executed.
+func (m *base[M, T]) WithTimeout(d time.Duration, clb func(v *T)) bool {
+	acquired, _ := m.write(func() bool { return m.tryLockFor(d) }, func(v *T) bool {
+		clb(v)
+		return true
+	})
+	return acquired
+}
+
+// tryLockFor repeatedly tries to acquire the lock until "d" has elapsed, backing off between attempts
+func (m *base[M, T]) tryLockFor(d time.Duration) bool {
+	l := any(m.m).(interface{ TryLock() bool })
+	deadline := time.Now().Add(d)
+	backoff := 10 * time.Microsecond
+	for {
+		if l.TryLock() {
+			return true
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		time.Sleep(min(backoff, remaining))
+		backoff = min(2*backoff, time.Millisecond)
+	}
+}
+
+// watch registers a new watcher channel, and returns a function to unregister it
+func (m *base[M, T]) watch() (<-chan T, func()) {
+	ch := make(chan T, 1)
+	m.Lock()
+	m.watchers = append(m.watchers, ch)
+	m.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.Lock()
+			defer m.Unlock()
+			m.watchers = slices.DeleteFunc(m.watchers, func(c chan T) bool { return c == ch })
+			close(ch)
+		})
+	}
+}
+
+// notify sends the current value to all watchers, and wakes up all WaitFor callers, the lock must be held.
+// Sends never block, if a watcher has not consumed the previous value yet, it is replaced by the new one.
+func (m *base[M, T]) notify() {
+	if m.cond != nil {
+		m.cond.Broadcast()
+	}
+	for _, ch := range m.watchers {
+		select {
+		case ch <- m.v:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- m.v
+		}
+	}
+}
+
+// waitFor blocks until pred returns true for the value, re-checking it after every notified mutation
+func (m *base[M, T]) waitFor(pred func(T) bool) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	c := m.condLocked()
+	for !pred(m.v) {
+		c.Wait()
+	}
+}
+
+// getCond returns the sync.Cond tied to the underlying mutex, creating it if needed
+func (m *base[M, T]) getCond() *sync.Cond {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return m.condLocked()
+}
+
+// condLocked returns the sync.Cond tied to the underlying mutex, creating it if needed, the lock must be held
+func (m *base[M, T]) condLocked() *sync.Cond {
+	if m.cond == nil {
+		m.cond = sync.NewCond(m.m)
+	}
+	return m.cond
+}
+
 //-----------------------------------------------------------------------------
 
 // generic helpers for sync.Mutex/sync.RWMutex
@@ -277,6 +858,9 @@ func (m *rwMtx[T]) RLock() { m.m.RLock() }
 // RUnlock exposes the underlying sync.RWMutex RUnlock function
 func (m *rwMtx[T]) RUnlock() { m.m.RUnlock() }
 
+// RLocker exposes the underlying sync.RWMutex RLocker function
+func (m *rwMtx[T]) RLocker() sync.Locker { return m.m.RLocker() }
+
 // RWithE provide a callback scope where the wrapped value can be safely used for Read only purposes
 func (m *rwMtx[T]) RWithE(clb func(v T) error) error {
 	m.RLock()
@@ -293,115 +877,1094 @@ func (m *rwMtx[T]) RWith(clb func(v T)) {
 }
 
 //-----------------------------------------------------------------------------
-// Methods for Mtx
 
-//-----------------------------------------------------------------------------
-// Methods for Map
+// number of TryLock attempts made by spinMutex before blocking
+const spinIterations = 32
 
-// Clear clears the map, removing all key-value pairs
-func (m *Map[K, V]) Clear() {
-	m.With(func(m *map[K]V) { clear(*m) })
-}
+// spinMutex is a sync.Mutex which spins for a bounded number of iterations
+// trying to acquire the lock before falling back to a blocking Lock
+type spinMutex struct{ Mutex }
 
-// Insert inserts a key/value in the map
-func (m *Map[K, V]) Insert(k K, v V) {
-	m.With(func(m *map[K]V) { (*m)[k] = v })
+// Lock spins on TryLock, then blocks until the lock is acquired
+func (m *spinMutex) Lock() {
+	for i := 0; i < spinIterations; i++ {
+		if m.TryLock() {
+			return
+		}
+	}
+	m.Mutex.Lock()
 }
 
-// Get returns the value corresponding to the key
-func (m *Map[K, V]) Get(k K) (out V, ok bool) {
-	m.RWith(func(mm map[K]V) { out, ok = mm[k] })
-	return
-}
+// generic helper for spinMutex
+type spinMtx[T any] struct{ *base[*spinMutex, T] }
 
-// GetKeyValue returns the key-value pair corresponding to the supplied key.
-func (m *Map[K, V]) GetKeyValue(k K) (key K, value V, ok bool) {
-	m.RWith(func(mm map[K]V) { value, ok = mm[k] })
-	if ok {
-		return k, value, true
-	}
-	return
+// newSpinMtxPtr creates a new spinMtx
+func newSpinMtxPtr[T any](v T) *spinMtx[T] { return &spinMtx[T]{newBase(&spinMutex{}, v)} }
+
+//-----------------------------------------------------------------------------
+
+// copy-on-write helper, every write publishes a new copy of the value
+// which readers can get through LoadShared without taking the lock
+type cowMtx[T any] struct {
+	*base[*Mutex, T]
+	shared atomic.Pointer[T]
 }
 
-// ContainsKey returns true if the map contains a value for the specified key
-func (m *Map[K, V]) ContainsKey(k K) (found bool) {
-	m.RWith(func(mm map[K]V) { _, found = mm[k] })
-	return
+// newCOWMtxPtr creates a new cowMtx
+func newCOWMtxPtr[T any](v T) *cowMtx[T] {
+	m := &cowMtx[T]{base: newBase(&Mutex{}, v)}
+	m.hook = m
+	m.publish()
+	return m
 }
 
-// Remove if the key exists, its value is returned to the caller and the key deleted from the map
-func (m *Map[K, V]) Remove(k K) (out V, ok bool) {
-	m.With(func(m *map[K]V) {
-		out, ok = (*m)[k]
-		if ok {
-			delete(*m, k)
-		}
-	})
-	return
+// publish stores a copy of the current value, the lock must be held
+func (m *cowMtx[T]) publish() { m.shared.Store(toPtr(m.v)) }
+
+// Unlock publishes the current value then unlocks the underlying sync.Mutex
+func (m *cowMtx[T]) Unlock() {
+	m.publish()
+	m.base.Unlock()
 }
 
-// Delete deletes a key from the map
-func (m *Map[K, V]) Delete(k K) {
-	m.With(func(m *map[K]V) { delete(*m, k) })
-	return
+func (m *cowMtx[T]) beforeWrite(*T) {}
+
+// afterWrite publishes the value, even if the callback reported no change, since it may have modified it anyway
+func (m *cowMtx[T]) afterWrite(*T, bool) func() {
+	m.publish()
+	return nil
 }
 
-// Len returns the length of the map
-func (m *Map[K, V]) Len() (out int) {
-	m.RWith(func(mm map[K]V) { out = len(mm) })
-	return
+// LoadShared returns the last published copy of the value without taking the lock
+func (m *cowMtx[T]) LoadShared() *T { return m.shared.Load() }
+
+//-----------------------------------------------------------------------------
+
+// observable helper, calls onChange with the old and new values after every write,
+// once the lock has been released
+type observableMtx[T any] struct {
+	*base[*Mutex, T]
+	onChange func(old, new T)
+	old      T // guarded by m, value before the write in progress
 }
 
-// IsEmpty returns true if the map contains no elements.
-func (m *Map[K, V]) IsEmpty() (out bool) {
-	m.RWith(func(mm map[K]V) { out = len(mm) == 0 })
-	return
+// newObservableMtxPtr creates a new observableMtx
+func newObservableMtxPtr[T any](v T, onChange func(old, new T)) *observableMtx[T] {
+	m := &observableMtx[T]{base: newBase(&Mutex{}, v), onChange: onChange}
+	m.hook = m
+	return m
 }
 
-// Each iterates each key/value of the map
-func (m *Map[K, V]) Each(clb func(K, V)) {
-	m.RWith(func(mm map[K]V) {
-		for k, v := range mm {
-			clb(k, v)
-		}
-	})
+// beforeWrite records the value before the write
+func (m *observableMtx[T]) beforeWrite(v *T) { m.old = *v }
+
+// afterWrite schedules the call to onChange, unless the callback reported no change
+func (m *observableMtx[T]) afterWrite(v *T, changed bool) func() {
+	old, cur := m.old, *v
+	m.old = *new(T)
+	if !changed {
+		return nil
+	}
+	return func() { m.onChange(old, cur) }
 }
 
-// Keys returns a slice of all keys
-func (m *Map[K, V]) Keys() (out []K) {
-	out = make([]K, 0)
-	m.RWith(func(mm map[K]V) {
-		for k := range mm {
-			out = append(out, k)
-		}
-	})
-	return
+//-----------------------------------------------------------------------------
+
+// lazyMutex is a sync.Mutex which calls init, under the lock, before it is acquired for the first time
+type lazyMutex struct {
+	Mutex
+	once sync.Once
+	init func()
 }
 
-// Values returns a slice of all values
-func (m *Map[K, V]) Values() (out []V) {
-	out = make([]V, 0)
-	m.RWith(func(mm map[K]V) {
-		for _, v := range mm {
-			out = append(out, v)
-		}
+// ensure calls init exactly once
+func (l *lazyMutex) ensure() {
+	l.once.Do(func() {
+		l.Mutex.Lock()
+		defer l.Mutex.Unlock()
+		l.init()
 	})
-	return
 }
 
-// Clone returns a clone of the map
-func (m *Map[K, V]) Clone() (out map[K]V) {
-	m.RWith(func(mm map[K]V) {
-		out = make(map[K]V, len(mm))
-		for k, v := range mm {
-			out[k] = v
-		}
-	})
-	return
+// Lock initializes the value if needed, then locks the underlying sync.Mutex
+func (l *lazyMutex) Lock() { l.ensure(); l.Mutex.Lock() }
+
+// TryLock initializes the value if needed, then tries to lock the underlying sync.Mutex
+func (l *lazyMutex) TryLock() bool { l.ensure(); return l.Mutex.TryLock() }
+
+// lazy helper, the value is initialized by calling init, under the lock, the first time it is accessed
+type lazyMtx[T any] struct{ *base[*lazyMutex, T] }
+
+// newLazyMtxPtr creates a new lazyMtx
+func newLazyMtxPtr[T any](init func() T) *lazyMtx[T] {
+	m := &lazyMtx[T]{newBase(&lazyMutex{}, *new(T))}
+	m.m.init = func() { m.v = init() }
+	return m
 }
 
+// GetPointer initializes the value if needed, then returns a pointer to the protected value
+func (m *lazyMtx[T]) GetPointer() *T { m.m.ensure(); return m.base.GetPointer() }
+
 //-----------------------------------------------------------------------------
-// Methods for Slice
+
+// debug helper, reports every time the write lock was held longer than a threshold
+type debugMtx[T any] struct {
+	*base[*Mutex, T]
+	threshold time.Duration
+	report    func(held time.Duration)
+	lockedAt  time.Time // guarded by m
+}
+
+// newDebugMtxPtr creates a new debugMtx
+func newDebugMtxPtr[T any](v T, threshold time.Duration, report func(held time.Duration)) *debugMtx[T] {
+	m := &debugMtx[T]{base: newBase(&Mutex{}, v), threshold: threshold, report: report}
+	m.hook = m
+	return m
+}
+
+// Lock locks the underlying sync.Mutex and records the acquisition time
+func (m *debugMtx[T]) Lock() {
+	m.base.Lock()
+	m.lockedAt = time.Now()
+}
+
+// Unlock unlocks the underlying sync.Mutex, then reports the hold duration if it exceeded the threshold
+func (m *debugMtx[T]) Unlock() {
+	held := time.Since(m.lockedAt)
+	m.base.Unlock()
+	if held > m.threshold {
+		m.report(held)
+	}
+}
+
+// beforeWrite records the acquisition time
+func (m *debugMtx[T]) beforeWrite(*T) { m.lockedAt = time.Now() }
+
+// afterWrite schedules the report if the lock was held longer than the threshold
+func (m *debugMtx[T]) afterWrite(*T, bool) func() {
+	if held := time.Since(m.lockedAt); held > m.threshold {
+		return func() { m.report(held) }
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// dirty helper, records whether the value was written since the last call to clearDirty
+type dirtyMtx[T any] struct {
+	*base[*Mutex, T]
+	dirty bool // guarded by m
+}
+
+// newDirtyMtxPtr creates a new dirtyMtx
+func newDirtyMtxPtr[T any](v T) *dirtyMtx[T] {
+	m := &dirtyMtx[T]{base: newBase(&Mutex{}, v)}
+	m.hook = m
+	return m
+}
+
+func (m *dirtyMtx[T]) beforeWrite(*T) {}
+
+// afterWrite marks the value as dirty, unless the callback reported no change
+func (m *dirtyMtx[T]) afterWrite(_ *T, changed bool) func() {
+	m.dirty = m.dirty || changed
+	return nil
+}
+
+// isDirty returns whether the value was written since the last call to clearDirty
+func (m *dirtyMtx[T]) isDirty() bool {
+	m.base.Lock()
+	defer m.base.Unlock()
+	return m.dirty
+}
+
+// clearDirty resets the dirty flag
+func (m *dirtyMtx[T]) clearDirty() {
+	m.base.Lock()
+	defer m.base.Unlock()
+	m.dirty = false
+}
+
+//-----------------------------------------------------------------------------
+
+// versioned helper, bumps a version counter on every write
+type versionedMtx[T any] struct {
+	*base[*Mutex, T]
+	version uint64 // guarded by m
+}
+
+// newVersionedMtxPtr creates a new versionedMtx
+func newVersionedMtxPtr[T any](v T) *versionedMtx[T] {
+	m := &versionedMtx[T]{base: newBase(&Mutex{}, v)}
+	m.hook = m
+	return m
+}
+
+func (m *versionedMtx[T]) beforeWrite(*T) {}
+
+// afterWrite bumps the version, unless the callback reported no change
+func (m *versionedMtx[T]) afterWrite(_ *T, changed bool) func() {
+	if changed {
+		m.version++
+	}
+	return nil
+}
+
+// getVersion returns the number of writes made so far
+func (m *versionedMtx[T]) getVersion() uint64 {
+	m.base.Lock()
+	defer m.base.Unlock()
+	return m.version
+}
+
+// compareVersionAndSwap stores newV only if the version is still "expected"
+func (m *versionedMtx[T]) compareVersionAndSwap(expected uint64, newV T) bool {
+	return m.withIf(func(v *T) bool {
+		if m.version != expected {
+			return false
+		}
+		*v = newV
+		return true
+	})
+}
+
+//-----------------------------------------------------------------------------
+// Methods for Mtx
+
+// LoadShared returns a pointer to the current value, the caller must treat it as read-only.
+// With a copy-on-write backend (NewCOWMtx) the pointer is shared between readers and obtained without locking,
+// otherwise a copy of the value is returned.
+func (m *Mtx[T]) LoadShared() *T {
+	if s, ok := m.Locker.(interface{ LoadShared() *T }); ok {
+		return s.LoadShared()
+	}
+	return toPtr(m.Load())
+}
+
+// IsDirty returns whether the value was written since creation or the last call to ClearDirty.
+// Always false if the Mtx was not created with NewDirtyMtx.
+func (m *Mtx[T]) IsDirty() bool {
+	if d, ok := m.Locker.(interface{ isDirty() bool }); ok {
+		return d.isDirty()
+	}
+	return false
+}
+
+// ClearDirty resets the flag returned by IsDirty, does nothing if the Mtx was not created with NewDirtyMtx
+func (m *Mtx[T]) ClearDirty() {
+	if d, ok := m.Locker.(interface{ clearDirty() }); ok {
+		d.clearDirty()
+	}
+}
+
+// Version returns a counter incremented by every write, starting at 0.
+// Always 0 if the Mtx was not created with NewVersionedMtx.
+func (m *Mtx[T]) Version() uint64 {
+	if v, ok := m.Locker.(interface{ getVersion() uint64 }); ok {
+		return v.getVersion()
+	}
+	return 0
+}
+
+// CompareVersionAndSwap stores newV only if the version is still "expected", and returns whether it was stored.
+// Always false if the Mtx was not created with NewVersionedMtx.
+func (m *Mtx[T]) CompareVersionAndSwap(expected uint64, newV T) bool {
+	if v, ok := m.Locker.(interface{ compareVersionAndSwap(uint64, T) bool }); ok {
+		return v.compareVersionAndSwap(expected, newV)
+	}
+	return false
+}
+
+// SwapFunc set the value to the result of f applied to the current value, and return the old value
+func (m *Mtx[T]) SwapFunc(f func(old T) T) (old T) { return swapFunc(m.Locker, f) }
+
+// RLocker returns a sync.Locker whose Lock/Unlock acquire/release the read lock,
+// or the write lock for backends without a read lock.
+// Panics if the Mtx was not created by one of this package's constructors.
+func (m Mtx[T]) RLocker() sync.Locker { return rLocker(m.Locker) }
+
+// WithRecover same as With, but recovers from a panic in the callback and returns it as an error.
+// The lock is released either way, and watchers are not notified.
+func (m Mtx[T]) WithRecover(clb func(v *T)) error { return withRecover(m.Locker, clb) }
+
+// WithTimeout same as With, but gives up if the lock cannot be acquired within "d".
+// Returns true if the callback was executed.
+// Panics if the Mtx was not created by one of this package's constructors.
+func (m Mtx[T]) WithTimeout(d time.Duration, clb func(v *T)) bool {
+	return withTimeout(m.Locker, d, clb)
+}
+
+// Clone returns a new Mtx, using the same kind of backend, holding a (shallow) copy of the current value.
+// The clone has its own independent lock, see cloneBackend for the backend-specific state.
+func (m *Mtx[T]) Clone() Mtx[T] { return Mtx[T]{Locker: cloneBackend(m.Locker), name: m.name} }
+
+// cloneBackend returns a new backend of the same kind as l, holding a (shallow) copy of its current value.
+// The new backend starts with a fresh state: not dirty, at version 0, debug and observable backends
+// share the report/onChange callbacks of l.
+// A lazy backend is initialized, and cloned as a sync.Mutex backend, as are backends not created by this package.
+func cloneBackend[T any](l Locker[T]) Locker[T] {
+	v := l.Load()
+	switch l := l.(type) {
+	case *rwMtx[T]:
+		return newRWMtxPtr(v)
+	case *spinMtx[T]:
+		return newSpinMtxPtr(v)
+	case *cowMtx[T]:
+		return newCOWMtxPtr(v)
+	case *debugMtx[T]:
+		return newDebugMtxPtr(v, l.threshold, l.report)
+	case *observableMtx[T]:
+		return newObservableMtxPtr(v, l.onChange)
+	case *dirtyMtx[T]:
+		return newDirtyMtxPtr(v)
+	case *versionedMtx[T]:
+		return newVersionedMtxPtr(v)
+	default:
+		return newMtxPtr(v)
+	}
+}
+
+// ToRWMtx returns a new Mtx with a sync.RWMutex as backend, holding a (shallow) copy of the current value.
+// The new Mtx has its own independent lock.
+func (m *Mtx[T]) ToRWMtx() Mtx[T] { return Mtx[T]{Locker: newRWMtxPtr(m.Load()), name: m.name} }
+
+// ToMtx returns a new Mtx with a sync.Mutex as backend, holding a (shallow) copy of the current value.
+// The new Mtx has its own independent lock.
+func (m *Mtx[T]) ToMtx() Mtx[T] { return Mtx[T]{Locker: newMtxPtr(m.Load()), name: m.name} }
+
+// Watch returns a channel which receives the new value after every Store/Swap/With mutation,
+// and a function to stop watching (which closes the channel).
+// Mutations never block on a slow watcher, it only receives the most recent value.
+// Mutations made through Lock/GetPointer/Unlock, and WithE callbacks returning an error, are not reported.
+// Panics if the Mtx was not created by one of this package's constructors.
+func (m *Mtx[T]) Watch() (<-chan T, func()) {
+	return backend[interface{ watch() (<-chan T, func()) }](m.Locker).watch()
+}
+
+// WaitFor blocks until pred returns true for the value. pred is called under the lock,
+// once immediately, then again after every Store/Swap/With mutation.
+// Mutations made through Lock/GetPointer/Unlock do not wake up the waiter.
+// Panics if the Mtx was not created by one of this package's constructors.
+func (m *Mtx[T]) WaitFor(pred func(T) bool) {
+	backend[interface{ waitFor(func(T) bool) }](m.Locker).waitFor(pred)
+}
+
+// Cond returns a sync.Cond tied to the underlying mutex, created on first call.
+// Every Store/Swap/With mutation calls Broadcast on it.
+//
+// Lock ownership rules:
+//   - Cond().L is the underlying mutex itself (the write lock for a RWMutex backend),
+//     so Wait must be called with the lock held through m.Lock(), never through m.RLock().
+//   - Wait releases the lock while waiting and re-acquires it before returning,
+//     so the value must be re-checked in a loop after Wait returns.
+//   - Mutations made through Lock/GetPointer/Unlock are not broadcast automatically,
+//     call Broadcast (or Signal) before unlocking.
+//   - Never call Wait from inside RWith/RWithE, which may only hold the read lock.
+//
+// Panics if the Mtx was not created by one of this package's constructors.
+func (m *Mtx[T]) Cond() *sync.Cond {
+	return backend[interface{ getCond() *sync.Cond }](m.Locker).getCond()
+}
+
+// IsZero reports whether the protected value is the zero value of T, or if the Mtx itself is the zero value.
+func (m Mtx[T]) IsZero() (out bool) {
+	if m.Locker == nil {
+		return true
+	}
+	m.RWith(func(v T) { out = reflect.ValueOf(&v).Elem().IsZero() })
+	return
+}
+
+// Name returns the name given at construction, empty if unnamed
+func (m Mtx[T]) Name() string { return m.name }
+
+// String implements fmt.Stringer, formatting the value under the read lock.
+// A zero Mtx is formatted as the zero value of T.
+func (m Mtx[T]) String() string { return withName(m.name, fmt.Sprintf("%v", loadOrZero(m.Locker))) }
+
+// GoString implements fmt.GoStringer, formatting the value under the read lock
+func (m Mtx[T]) GoString() string { return goStringWithName(m, m.name, loadOrZero(m.Locker)) }
+
+//-----------------------------------------------------------------------------
+// Methods for Map
+
+// WithE provide a callback scope where the wrapped map can be safely used,
+// and refreshes the length reported by ApproxLen.
+// The write methods use value receivers so that a Map value still implements Locker.
+func (m Map[K, V]) WithE(clb func(v *map[K]V) error) error {
+	return m.Locker.WithE(func(mm *map[K]V) error {
+		defer func() { m.updateCount(*mm) }()
+		return clb(mm)
+	})
+}
+
+// With same as WithE but do return an error
+func (m Map[K, V]) With(clb func(v *map[K]V)) {
+	_ = m.WithE(func(tx *map[K]V) error {
+		clb(tx)
+		return nil
+	})
+}
+
+// Store a new map, reported to the write-ahead log hook as a clear followed by an insert per entry
+func (m Map[K, V]) Store(newV map[K]V) {
+	m.SwapFunc(func(map[K]V) map[K]V { return newV })
+}
+
+// Swap set a new map and return the old map, reported to the write-ahead log hook as a clear followed by an insert per entry
+func (m Map[K, V]) Swap(newVal map[K]V) (old map[K]V) {
+	return m.SwapFunc(func(map[K]V) map[K]V { return newVal })
+}
+
+// SwapFunc set the map to the result of f applied to the current map, and return the old map.
+// It is reported to the write-ahead log hook as a clear followed by an insert per entry.
+func (m Map[K, V]) SwapFunc(f func(old map[K]V) map[K]V) (old map[K]V) {
+	m.With(func(v *map[K]V) {
+		old = *v
+		*v = f(old)
+		m.logReplace(*v)
+	})
+	return
+}
+
+// RLocker same as Mtx.RLocker
+func (m Map[K, V]) RLocker() sync.Locker { return rLocker(m.Locker) }
+
+// WithRecover same as With, but recovers from a panic in the callback and returns it as an error
+func (m Map[K, V]) WithRecover(clb func(v *map[K]V)) error { return withRecover[map[K]V](m, clb) }
+
+// WithTimeout same as With, but gives up if the lock cannot be acquired within "d"
+func (m Map[K, V]) WithTimeout(d time.Duration, clb func(v *map[K]V)) bool {
+	return withTimeout(m.Locker, d, func(mm *map[K]V) {
+		defer func() { m.updateCount(*mm) }()
+		clb(mm)
+	})
+}
+
+// updateCount stores the length of mm for ApproxLen, the lock must be held
+func (m Map[K, V]) updateCount(mm map[K]V) {
+	if m.state != nil {
+		m.state.count.Store(int64(len(mm)))
+	}
+}
+
+// ApproxLen returns the length of the map as of the last write, without taking the lock.
+// Writes made through Lock/GetPointer are only accounted for after the next locked write.
+// The counter is set up by the constructors, for a Map built otherwise (eg: Map[K, V]{Locker: l})
+// ApproxLen falls back to Len, which takes the read lock.
+func (m *Map[K, V]) ApproxLen() int {
+	if m.state == nil {
+		if m.Locker == nil {
+			return 0
+		}
+		return m.Len()
+	}
+	return int(m.state.count.Load())
+}
+
+// Operations reported to the write-ahead log hook of a Map (see SetWAL)
+const (
+	WALInsert = "insert"
+	WALDelete = "delete"
+	WALClear  = "clear"
+)
+
+// SetWAL sets a write-ahead log hook, called by Insert/InsertMany/InsertPairs/LoadOrStore/ComputeIfAbsent/ComputeIfPresent/Delete/DeleteFunc/Remove/LoadAndDelete/Pop/Clear/Drain/Update/ReplaceAll/Rebuild/Merge/MergeFunc
+// with the operation and the affected key/value.
+// Store/Swap/SwapFunc/Transaction/TxE replace the whole map, and are reported as a clear followed by an insert per entry.
+// The hook is called synchronously while the write lock is held, so the log and the map cannot diverge,
+// but a slow hook will block every other user of the map.
+// Mutations made through With/WithE/WithRecover/WithTimeout/GetPointer are not reported.
+// The hook is shared by all copies of the Map. For a Map built without a constructor (eg: Map[K, V]{Locker: l}),
+// SetWAL must be called before the Map is copied or shared.
+func (m *Map[K, V]) SetWAL(w func(op string, k K, v V)) {
+	m.With(func(mm *map[K]V) {
+		if m.state == nil {
+			m.state = newMapState[K, V](len(*mm))
+		}
+		m.state.wal = w
+	})
+}
+
+// reports an operation to the write-ahead log hook, must be called with the write lock held
+func (m Map[K, V]) log(op string, k K, v V) {
+	if m.state != nil && m.state.wal != nil {
+		m.state.wal(op, k, v)
+	}
+}
+
+// reports the replacement of the whole map by mm, as a clear followed by an insert per entry,
+// must be called with the write lock held
+func (m Map[K, V]) logReplace(mm map[K]V) {
+	var zeroK K
+	var zeroV V
+	m.log(WALClear, zeroK, zeroV)
+	for k, v := range mm {
+		m.log(WALInsert, k, v)
+	}
+}
+
+// Clear clears the map, removing all key-value pairs
+func (m *Map[K, V]) Clear() {
+	m.With(func(mm *map[K]V) {
+		clear(*mm)
+		var zeroK K
+		var zeroV V
+		m.log(WALClear, zeroK, zeroV)
+	})
+}
+
+// Drain returns the current map and replaces it with an empty one, in a single locked section
+func (m *Map[K, V]) Drain() (out map[K]V) {
+	m.With(func(mm *map[K]V) {
+		out = *mm
+		*mm = make(map[K]V)
+		var zeroK K
+		var zeroV V
+		m.log(WALClear, zeroK, zeroV)
+	})
+	return
+}
+
+// Insert inserts a key/value in the map
+func (m *Map[K, V]) Insert(k K, v V) {
+	m.With(func(mm *map[K]V) {
+		(*mm)[k] = v
+		m.log(WALInsert, k, v)
+	})
+}
+
+// InsertMany inserts all entries in the map under a single lock, overwriting existing keys
+func (m *Map[K, V]) InsertMany(entries map[K]V) { m.Merge(entries) }
+
+// InsertPairs inserts all key/value pairs in the map under a single lock, overwriting existing keys.
+// If a key appears more than once, the last pair wins.
+func (m *Map[K, V]) InsertPairs(pairs ...Pair[K, V]) {
+	m.With(func(mm *map[K]V) {
+		for _, p := range pairs {
+			(*mm)[p.Key] = p.Value
+			m.log(WALInsert, p.Key, p.Value)
+		}
+	})
+}
+
+// Get returns the value corresponding to the key
+func (m *Map[K, V]) Get(k K) (out V, ok bool) {
+	m.RWith(func(mm map[K]V) { out, ok = mm[k] })
+	return
+}
+
+// GetKeyValue returns the key-value pair corresponding to the supplied key.
+func (m *Map[K, V]) GetKeyValue(k K) (key K, value V, ok bool) {
+	m.RWith(func(mm map[K]V) { value, ok = mm[k] })
+	if ok {
+		return k, value, true
+	}
+	return
+}
+
+// GetMany returns the entries for the given keys in a single locked section, absent keys are omitted
+func (m *Map[K, V]) GetMany(keys []K) (out map[K]V) {
+	out = make(map[K]V, len(keys))
+	m.RWith(func(mm map[K]V) {
+		for _, k := range keys {
+			if v, ok := mm[k]; ok {
+				out[k] = v
+			}
+		}
+	})
+	return
+}
+
+// ContainsKey returns true if the map contains a value for the specified key
+func (m *Map[K, V]) ContainsKey(k K) (found bool) {
+	m.RWith(func(mm map[K]V) { _, found = mm[k] })
+	return
+}
+
+// Remove if the key exists, its value is returned to the caller and the key deleted from the map
+func (m *Map[K, V]) Remove(k K) (out V, ok bool) {
+	m.With(func(mm *map[K]V) {
+		out, ok = (*mm)[k]
+		if ok {
+			delete(*mm, k)
+			m.log(WALDelete, k, out)
+		}
+	})
+	return
+}
+
+// Pop removes and returns an arbitrary key/value pair from the map, or false if the map is empty
+func (m *Map[K, V]) Pop() (k K, v V, ok bool) {
+	m.With(func(mm *map[K]V) {
+		for k, v = range *mm {
+			ok = true
+			delete(*mm, k)
+			m.log(WALDelete, k, v)
+			return
+		}
+	})
+	return
+}
+
+// LoadAndDelete same as Remove, named after sync.Map.LoadAndDelete
+func (m *Map[K, V]) LoadAndDelete(k K) (value V, loaded bool) { return m.Remove(k) }
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *Map[K, V]) LoadOrStore(k K, v V) (actual V, loaded bool) {
+	m.With(func(mm *map[K]V) {
+		if actual, loaded = (*mm)[k]; !loaded {
+			(*mm)[k], actual = v, v
+			m.log(WALInsert, k, v)
+		}
+	})
+	return
+}
+
+// Delete deletes a key from the map
+func (m *Map[K, V]) Delete(k K) {
+	m.With(func(mm *map[K]V) {
+		if v, ok := (*mm)[k]; ok {
+			delete(*mm, k)
+			m.log(WALDelete, k, v)
+		}
+	})
+	return
+}
+
+// DeleteFunc deletes every key/value pair that satisfies pred, and returns the number of deleted keys
+func (m *Map[K, V]) DeleteFunc(pred func(K, V) bool) (out int) {
+	m.With(func(mm *map[K]V) {
+		for k, v := range *mm {
+			if pred(k, v) {
+				delete(*mm, k)
+				m.log(WALDelete, k, v)
+				out++
+			}
+		}
+	})
+	return
+}
+
+// Len returns the length of the map
+func (m *Map[K, V]) Len() (out int) {
+	m.RWith(func(mm map[K]V) { out = len(mm) })
+	return
+}
+
+// IsEmpty returns true if the map contains no elements.
+func (m *Map[K, V]) IsEmpty() (out bool) {
+	m.RWith(func(mm map[K]V) { out = len(mm) == 0 })
+	return
+}
+
+// Each iterates each key/value of the map
+func (m *Map[K, V]) Each(clb func(K, V)) {
+	m.RWith(func(mm map[K]V) {
+		for k, v := range mm {
+			clb(k, v)
+		}
+	})
+}
+
+// EachUntil iterates each key/value of the map until clb returns false
+func (m *Map[K, V]) EachUntil(clb func(K, V) bool) {
+	m.RWith(func(mm map[K]V) {
+		for k, v := range mm {
+			if !clb(k, v) {
+				return
+			}
+		}
+	})
+}
+
+// Keys returns a slice of all keys
+func (m *Map[K, V]) Keys() (out []K) {
+	out = make([]K, 0)
+	m.RWith(func(mm map[K]V) {
+		for k := range mm {
+			out = append(out, k)
+		}
+	})
+	return
+}
+
+// KeysFunc returns the keys of the map sorted using less.
+// Keys are collected under the read lock, and sorted after it is released.
+func (m *Map[K, V]) KeysFunc(less func(a, b K) bool) (out []K) {
+	out = m.Keys()
+	slices.SortFunc(out, lessToCmp(less))
+	return
+}
+
+// Entries returns a slice of all key/value pairs
+func (m *Map[K, V]) Entries() (out []Pair[K, V]) {
+	m.RWith(func(mm map[K]V) {
+		out = make([]Pair[K, V], 0, len(mm))
+		for k, v := range mm {
+			out = append(out, Pair[K, V]{k, v})
+		}
+	})
+	return
+}
+
+// SortedEntriesFunc returns all key/value pairs sorted using less.
+// Pairs are collected under the read lock, and sorted after it is released.
+func (m *Map[K, V]) SortedEntriesFunc(less func(a, b Pair[K, V]) bool) (out []Pair[K, V]) {
+	out = m.Entries()
+	slices.SortFunc(out, lessToCmp(less))
+	return
+}
+
+// Values returns a slice of all values
+func (m *Map[K, V]) Values() (out []V) {
+	out = make([]V, 0)
+	m.RWith(func(mm map[K]V) {
+		for _, v := range mm {
+			out = append(out, v)
+		}
+	})
+	return
+}
+
+// Clone returns a clone of the map
+func (m *Map[K, V]) Clone() (out map[K]V) {
+	m.RWith(func(mm map[K]V) {
+		out = make(map[K]V, len(mm))
+		for k, v := range mm {
+			out[k] = v
+		}
+	})
+	return
+}
+
+// Update atomically reads the value for k, passes it to f along with whether the key existed,
+// and stores the result of f
+func (m *Map[K, V]) Update(k K, f func(old V, existed bool) V) {
+	m.With(func(mm *map[K]V) {
+		old, existed := (*mm)[k]
+		v := f(old, existed)
+		(*mm)[k] = v
+		m.log(WALInsert, k, v)
+	})
+}
+
+// ReplaceAll replaces each value with the result of f, all in a single locked section
+func (m *Map[K, V]) ReplaceAll(f func(K, V) V) {
+	m.With(func(mm *map[K]V) {
+		for k, v := range *mm {
+			v = f(k, v)
+			(*mm)[k] = v
+			m.log(WALInsert, k, v)
+		}
+	})
+}
+
+// ComputeIfAbsent returns the value for k if present.
+// Otherwise, it stores and returns the result of f(k), all in a single locked section.
+func (m *Map[K, V]) ComputeIfAbsent(k K, f func(K) V) (out V) {
+	m.With(func(mm *map[K]V) {
+		var ok bool
+		if out, ok = (*mm)[k]; !ok {
+			out = f(k)
+			(*mm)[k] = out
+			m.log(WALInsert, k, out)
+		}
+	})
+	return
+}
+
+// ComputeIfPresent recomputes the value for k if present, using f, all in a single locked section.
+// If f returns false for keep, the key is deleted. Returns the new value, and whether the key is (still) in the map.
+func (m *Map[K, V]) ComputeIfPresent(k K, f func(K, V) (V, bool)) (out V, ok bool) {
+	m.With(func(mm *map[K]V) {
+		old, existed := (*mm)[k]
+		if !existed {
+			return
+		}
+		v, keep := f(k, old)
+		if !keep {
+			delete(*mm, k)
+			m.log(WALDelete, k, old)
+			return
+		}
+		(*mm)[k], out, ok = v, v, true
+		m.log(WALInsert, k, v)
+	})
+	return
+}
+
+// Merge inserts all key/value pairs of other into the map, overwriting existing keys
+func (m *Map[K, V]) Merge(other map[K]V) {
+	m.With(func(mm *map[K]V) {
+		for k, v := range other {
+			(*mm)[k] = v
+			m.log(WALInsert, k, v)
+		}
+	})
+}
+
+// MergeFunc inserts all key/value pairs of other into the map,
+// calling resolve to decide which value to keep when a key already exists
+func (m *Map[K, V]) MergeFunc(other map[K]V, resolve func(k K, existing, incoming V) V) {
+	m.With(func(mm *map[K]V) {
+		for k, v := range other {
+			if existing, ok := (*mm)[k]; ok {
+				v = resolve(k, existing, v)
+			}
+			(*mm)[k] = v
+			m.log(WALInsert, k, v)
+		}
+	})
+}
+
+// Transaction provide a callback scope with direct access to the map, under the write lock,
+// so that multiple interdependent edits can be made atomically.
+// The map must not escape the callback.
+// It is reported to the write-ahead log hook as a clear followed by an insert per entry.
+func (m *Map[K, V]) Transaction(f func(raw map[K]V)) {
+	m.With(func(mm *map[K]V) {
+		f(*mm)
+		m.logReplace(*mm)
+	})
+}
+
+// TxE runs f on a clone of the map, under the write lock.
+// The clone replaces the map only if f returns nil, otherwise the map is left untouched and the error is returned.
+// A commit is reported to the write-ahead log hook as a clear followed by an insert per entry.
+func (m *Map[K, V]) TxE(f func(m map[K]V) error) error {
+	return m.WithE(func(mm *map[K]V) error {
+		clone := make(map[K]V, len(*mm))
+		maps.Copy(clone, *mm)
+		if err := f(clone); err != nil {
+			return err
+		}
+		*mm = clone
+		m.logReplace(*mm)
+		return nil
+	})
+}
+
+// Rebuild replaces the map with the one returned by f, which receives a clone of the current map,
+// all in a single locked section, so readers see either the old or the new map, never a mix of both.
+// A nil map returned by f is replaced by an empty one.
+func (m *Map[K, V]) Rebuild(f func(old map[K]V) map[K]V) {
+	m.With(func(mm *map[K]V) {
+		clone := make(map[K]V, len(*mm))
+		maps.Copy(clone, *mm)
+		*mm = defaultMap(f(clone))
+		m.logReplace(*mm)
+	})
+}
+
+// EachParallel snapshots the map under the read lock, then calls clb for each key/value
+// using "workers" goroutines. The lock is not held while clb runs.
+func (m *Map[K, V]) EachParallel(workers int, clb func(K, V)) {
+	type entry struct {
+		k K
+		v V
+	}
+	entries := make(chan entry)
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range entries {
+				clb(e.k, e.v)
+			}
+		}()
+	}
+	for k, v := range m.Clone() {
+		entries <- entry{k, v}
+	}
+	close(entries)
+	wg.Wait()
+}
+
+// Iter returns an iterator over the key/value pairs of the map.
+// WARNING: the read lock is held for the whole duration of the iteration,
+// calling other methods of the same map inside the loop may deadlock.
+func (m *Map[K, V]) Iter() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.RWith(func(mm map[K]V) {
+			for k, v := range mm {
+				if !yield(k, v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// IterSnapshot returns an iterator over a clone of the map.
+// No lock is held during the iteration, so the map can safely be modified inside the loop.
+func (m *Map[K, V]) IterSnapshot() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range m.Clone() {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// IsZero reports whether the map is empty, or if the Map itself is the zero value.
+func (m Map[K, V]) IsZero() (out bool) {
+	if m.Locker == nil {
+		return true
+	}
+	m.RWith(func(mm map[K]V) { out = len(mm) == 0 })
+	return
+}
+
+// Name returns the name given at construction, empty if unnamed
+func (m Map[K, V]) Name() string { return m.name }
+
+// String implements fmt.Stringer, formatting the map under the read lock.
+// A zero Map is formatted as an empty map.
+func (m Map[K, V]) String() (out string) {
+	if m.Locker == nil {
+		return withName(m.name, fmt.Sprintf("%v", map[K]V(nil)))
+	}
+	m.RWith(func(mm map[K]V) { out = withName(m.name, fmt.Sprintf("%v", mm)) })
+	return
+}
+
+// GoString implements fmt.GoStringer, formatting the map under the read lock
+func (m Map[K, V]) GoString() (out string) {
+	if m.Locker == nil {
+		return goStringWithName(m, m.name, map[K]V(nil))
+	}
+	m.RWith(func(mm map[K]V) { out = goStringWithName(m, m.name, mm) })
+	return
+}
+
+//-----------------------------------------------------------------------------
+// Functions for Map
+
+// MapKeysWhere returns the keys of the entries that satisfy pred
+func MapKeysWhere[K comparable, V any](m *Map[K, V], pred func(K, V) bool) (out []K) {
+	out = make([]K, 0)
+	m.RWith(func(mm map[K]V) {
+		for k, v := range mm {
+			if pred(k, v) {
+				out = append(out, k)
+			}
+		}
+	})
+	return
+}
+
+// MapSortedKeys returns the keys of the map in ascending order.
+// Keys are collected under the read lock, and sorted after it is released.
+func MapSortedKeys[K cmp.Ordered, V any](m *Map[K, V]) (out []K) {
+	out = m.Keys()
+	slices.Sort(out)
+	return
+}
+
+// MapCompareAndDelete deletes the entry for k if its value is equal to old, and returns whether it was deleted
+func MapCompareAndDelete[K, V comparable](m *Map[K, V], k K, old V) (deleted bool) {
+	m.With(func(mm *map[K]V) {
+		if v, ok := (*mm)[k]; ok && v == old {
+			delete(*mm, k)
+			m.log(WALDelete, k, v)
+			deleted = true
+		}
+	})
+	return
+}
+
+// MapCount returns the number of entries for which pred returns true
+func MapCount[K comparable, V any](m *Map[K, V], pred func(K, V) bool) (out int) {
+	m.RWith(func(mm map[K]V) {
+		for k, v := range mm {
+			if pred(k, v) {
+				out++
+			}
+		}
+	})
+	return
+}
+
+// MapAny returns true if pred returns true for at least one entry, false for an empty map
+func MapAny[K comparable, V any](m *Map[K, V], pred func(K, V) bool) (out bool) {
+	m.RWith(func(mm map[K]V) {
+		for k, v := range mm {
+			if pred(k, v) {
+				out = true
+				return
+			}
+		}
+	})
+	return
+}
+
+// MapAll returns true if pred returns true for every entry, true for an empty map
+func MapAll[K comparable, V any](m *Map[K, V], pred func(K, V) bool) (out bool) {
+	out = true
+	m.RWith(func(mm map[K]V) {
+		for k, v := range mm {
+			if !pred(k, v) {
+				out = false
+				return
+			}
+		}
+	})
+	return
+}
+
+// MapInvert returns a new map with the keys and values swapped.
+// If several keys hold the same value, the last one visited wins, and since map iteration order is random,
+// which of those keys ends up in the result is unspecified.
+func MapInvert[K, V comparable](m *Map[K, V]) (out map[V]K) {
+	m.RWith(func(mm map[K]V) {
+		out = make(map[V]K, len(mm))
+		for k, v := range mm {
+			out[v] = k
+		}
+	})
+	return
+}
+
+// TransferMapEntry moves the entry for k from "from" to "to", under both write locks,
+// so the entry is never observable in both maps or in neither.
+// Returns false if "from" has no entry for k.
+func TransferMapEntry[K comparable, V any](from, to IMap[K, V], k K) (moved bool) {
+	With2(from, to, func(src, dst *map[K]V) {
+		var v V
+		if v, moved = (*src)[k]; !moved {
+			return
+		}
+		delete(*src, k)
+		(*dst)[k] = v
+		if m, ok := from.(*Map[K, V]); ok {
+			m.log(WALDelete, k, v)
+		}
+		if m, ok := to.(*Map[K, V]); ok {
+			m.log(WALInsert, k, v)
+		}
+	})
+	return
+}
+
+// MapEqual returns true if both maps hold the same keys with equal values.
+// Both read locks are acquired in address order to prevent deadlocks.
+func MapEqual[K, V comparable](a, b IMap[K, V]) (out bool) {
+	RWith2(a, b, func(x, y map[K]V) { out = maps.Equal(x, y) })
+	return
+}
+
+// MapEqualFunc same as MapEqual, but values are compared using eq
+func MapEqualFunc[K comparable, V1, V2 any](a IMap[K, V1], b IMap[K, V2], eq func(V1, V2) bool) (out bool) {
+	RWith2(a, b, func(x map[K]V1, y map[K]V2) { out = maps.EqualFunc(x, y, eq) })
+	return
+}
+
+//-----------------------------------------------------------------------------
+// Methods for Slice
 
 // Each iterates each values of the slice
 func (s *Slice[T]) Each(clb func(T)) {
@@ -412,31 +1975,105 @@ func (s *Slice[T]) Each(clb func(T)) {
 	})
 }
 
+// EachUntil iterates each values of the slice until clb returns false
+func (s *Slice[T]) EachUntil(clb func(T) bool) {
+	s.RWith(func(v []T) {
+		for _, e := range v {
+			if !clb(e) {
+				return
+			}
+		}
+	})
+}
+
 // Clear clears the slice, removing all values
 func (s *Slice[T]) Clear() {
 	s.With(func(v *[]T) { *v = nil; *v = make([]T, 0) })
 }
 
+// Drain returns the current slice and replaces it with an empty one, in a single locked section
+func (s *Slice[T]) Drain() (out []T) {
+	s.With(func(v *[]T) { out = *v; *v = make([]T, 0) })
+	return
+}
+
 // Append appends elements at the end of the slice
 func (s *Slice[T]) Append(els ...T) {
 	s.With(func(v *[]T) { *v = append(*v, els...) })
 }
 
-// Unshift insert new element at beginning of the slice
-func (s *Slice[T]) Unshift(el T) {
-	s.With(func(v *[]T) { *v = append([]T{el}, *v...) })
+// Unshift insert new element at beginning of the slice
+func (s *Slice[T]) Unshift(el T) {
+	s.With(func(v *[]T) { *v = append([]T{el}, *v...) })
+}
+
+// Shift (pop front) remove and return the first element from the slice
+func (s *Slice[T]) Shift() (out T) {
+	s.With(func(v *[]T) { out, *v = (*v)[0], (*v)[1:] })
+	return
+}
+
+// Pop remove and return the last element from the slice
+func (s *Slice[T]) Pop() (out T) {
+	s.With(func(v *[]T) { out, *v = (*v)[len(*v)-1], (*v)[:len(*v)-1] })
+	return
+}
+
+// ShiftOk same as Shift, but returns false instead of panicking if the slice is empty
+func (s *Slice[T]) ShiftOk() (out T, ok bool) {
+	s.With(func(v *[]T) {
+		if len(*v) > 0 {
+			out, *v, ok = (*v)[0], (*v)[1:], true
+		}
+	})
+	return
+}
+
+// PopOk same as Pop, but returns false instead of panicking if the slice is empty
+func (s *Slice[T]) PopOk() (out T, ok bool) {
+	s.With(func(v *[]T) {
+		if len(*v) > 0 {
+			out, *v, ok = (*v)[len(*v)-1], (*v)[:len(*v)-1], true
+		}
+	})
+	return
+}
+
+// ShiftN removes and returns up to n elements from the front of the slice, in order.
+// Returns fewer elements if the slice is shorter, never panics.
+func (s *Slice[T]) ShiftN(n int) (out []T) {
+	s.With(func(v *[]T) {
+		n = min(max(n, 0), len(*v))
+		out = slices.Clone((*v)[:n])
+		*v = (*v)[n:]
+	})
+	return
 }
 
-// Shift (pop front) remove and return the first element from the slice
-func (s *Slice[T]) Shift() (out T) {
-	s.With(func(v *[]T) { out, *v = (*v)[0], (*v)[1:] })
+// PopN removes and returns up to n elements from the back of the slice, in order.
+// Returns fewer elements if the slice is shorter, never panics.
+func (s *Slice[T]) PopN(n int) (out []T) {
+	s.With(func(v *[]T) {
+		n = min(max(n, 0), len(*v))
+		out = slices.Clone((*v)[len(*v)-n:])
+		clear((*v)[len(*v)-n:])
+		*v = (*v)[:len(*v)-n]
+	})
 	return
 }
 
-// Pop remove and return the last element from the slice
-func (s *Slice[T]) Pop() (out T) {
-	s.With(func(v *[]T) { out, *v = (*v)[len(*v)-1], (*v)[:len(*v)-1] })
-	return
+// SwapFunc set the slice to the result of f applied to the current slice, and return the old slice
+func (s *Slice[T]) SwapFunc(f func(old []T) []T) (old []T) { return swapFunc(s.Locker, f) }
+
+// RLocker same as Mtx.RLocker
+func (s Slice[T]) RLocker() sync.Locker { return rLocker(s.Locker) }
+
+// WithRecover same as Mtx.WithRecover
+func (s Slice[T]) WithRecover(clb func(v *[]T)) error { return withRecover(s.Locker, clb) }
+
+// WithTimeout same as Mtx.WithTimeout
+func (s Slice[T]) WithTimeout(d time.Duration, clb func(v *[]T)) bool {
+	return withTimeout(s.Locker, d, clb)
 }
 
 // Clone returns a clone of the slice
@@ -448,6 +2085,19 @@ func (s *Slice[T]) Clone() (out []T) {
 	return
 }
 
+// CopyInto copies the first min(len(dst), len(slice)) elements into dst without allocating,
+// and returns the number of elements copied
+func (s *Slice[T]) CopyInto(dst []T) (n int) {
+	s.RWith(func(v []T) { n = copy(dst, v) })
+	return
+}
+
+// Cap returns the capacity of the underlying slice
+func (s *Slice[T]) Cap() (out int) {
+	s.RWith(func(v []T) { out = cap(v) })
+	return
+}
+
 // Len returns the length of the slice
 func (s *Slice[T]) Len() (out int) {
 	s.RWith(func(v []T) { out = len(v) })
@@ -466,6 +2116,51 @@ func (s *Slice[T]) Get(i int) (out T) {
 	return
 }
 
+// Set overwrites the element at index i with el, panics if i is out of range
+func (s *Slice[T]) Set(i int, el T) {
+	s.With(func(v *[]T) { (*v)[i] = el })
+}
+
+// Move moves the element at index "from" to index "to", shifting the elements in between.
+// Panics if either index is out of range, the slice is left untouched in that case.
+func (s *Slice[T]) Move(from, to int) {
+	s.With(func(v *[]T) {
+		el, _ := (*v)[from], (*v)[to]
+		if from < to {
+			copy((*v)[from:to], (*v)[from+1:to+1])
+		} else {
+			copy((*v)[to+1:from+1], (*v)[to:from])
+		}
+		(*v)[to] = el
+	})
+}
+
+// Rotate rotates the elements left by n positions (right if n is negative), wrapping around the length
+func (s *Slice[T]) Rotate(n int) {
+	s.With(func(v *[]T) {
+		l := len(*v)
+		if l == 0 {
+			return
+		}
+		n = (n%l + l) % l
+		slices.Reverse((*v)[:n])
+		slices.Reverse((*v)[n:])
+		slices.Reverse(*v)
+	})
+}
+
+// SwapAt swaps the elements at index i and j, panics if either index is out of range
+func (s *Slice[T]) SwapAt(i, j int) {
+	s.With(func(v *[]T) { (*v)[i], (*v)[j] = (*v)[j], (*v)[i] })
+}
+
+// SwapContents exchanges the backing slices of s and other, atomically for readers of either.
+// Both locks are acquired in address order (see With2) to prevent deadlocks, other can be s itself.
+// If either side is a BoundedSlice, the slice it receives is trimmed to its bound.
+func (s *Slice[T]) SwapContents(other *Slice[T]) {
+	With2[[]T, []T](s, other, func(x, y *[]T) { *x, *y = *y, *x })
+}
+
 // Remove removes the element at position i within the slice,
 // shifting all elements after it to the left
 // Panics if index is out of bounds
@@ -497,6 +2192,380 @@ func (s *Slice[T]) Filter(keep func(el T) bool) (out []T) {
 	return
 }
 
+// FilterInto appends the elements that satisfy the "keep" predicate callback to dst[:0], and returns the result.
+// The backing array of dst is reused when its capacity suffices, so no allocation is made.
+func (s *Slice[T]) FilterInto(dst []T, keep func(el T) bool) (out []T) {
+	s.RWith(func(v []T) {
+		out = dst[:0]
+		for _, x := range v {
+			if keep(x) {
+				out = append(out, x)
+			}
+		}
+	})
+	return
+}
+
+// Grow increases the capacity of the slice, if necessary, to guarantee space for another n elements
+func (s *Slice[T]) Grow(n int) {
+	s.With(func(v *[]T) { *v = slices.Grow(*v, n) })
+}
+
+// Truncate cuts the slice to length n, zeroing the dropped elements. Panics if n > len.
+func (s *Slice[T]) Truncate(n int) {
+	s.With(func(v *[]T) {
+		if n > len(*v) {
+			panic(fmt.Sprintf("mtx: Truncate length %d out of range [0:%d]", n, len(*v)))
+		}
+		clear((*v)[n:])
+		*v = (*v)[:n]
+	})
+}
+
+// Resize grows the slice to length n, appending "fill" for the new elements,
+// or truncates it to length n, zeroing the dropped elements
+func (s *Slice[T]) Resize(n int, fill T) {
+	s.With(func(v *[]T) {
+		if n < len(*v) {
+			clear((*v)[n:])
+			*v = (*v)[:n]
+			return
+		}
+		for len(*v) < n {
+			*v = append(*v, fill)
+		}
+	})
+}
+
+// SortFunc sorts the slice in place using the cmp function
+func (s *Slice[T]) SortFunc(cmp func(a, b T) int) {
+	s.With(func(v *[]T) { slices.SortFunc(*v, cmp) })
+}
+
+// UpdateEach replaces each element of the slice with the result of f, which receives the element index
+func (s *Slice[T]) UpdateEach(f func(i int, el T) T) {
+	s.With(func(v *[]T) {
+		for i, e := range *v {
+			(*v)[i] = f(i, e)
+		}
+	})
+}
+
+// First returns the first element of the slice, or false if the slice is empty
+func (s *Slice[T]) First() (out T, ok bool) {
+	s.RWith(func(v []T) {
+		if len(v) > 0 {
+			out, ok = v[0], true
+		}
+	})
+	return
+}
+
+// Last returns the last element of the slice, or false if the slice is empty
+func (s *Slice[T]) Last() (out T, ok bool) {
+	s.RWith(func(v []T) {
+		if len(v) > 0 {
+			out, ok = v[len(v)-1], true
+		}
+	})
+	return
+}
+
+// Peek is an alias for Last
+func (s *Slice[T]) Peek() (out T, ok bool) { return s.Last() }
+
+// Iter returns an iterator over the elements of the slice.
+// WARNING: the read lock is held for the whole duration of the iteration,
+// calling other methods of the same slice inside the loop may deadlock.
+func (s *Slice[T]) Iter() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.RWith(func(v []T) {
+			for _, e := range v {
+				if !yield(e) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// FirstWhere returns the first element that satisfies pred, or false if none does
+func (s *Slice[T]) FirstWhere(pred func(T) bool) (out T, ok bool) {
+	s.RWith(func(v []T) {
+		for _, e := range v {
+			if pred(e) {
+				out, ok = e, true
+				return
+			}
+		}
+	})
+	return
+}
+
+// IterSnapshot returns an iterator over a clone of the slice.
+// No lock is held during the iteration, so the slice can safely be modified inside the loop.
+func (s *Slice[T]) IterSnapshot() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, e := range s.Clone() {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// IsZero reports whether the slice is empty, or if the Slice itself is the zero value.
+func (s Slice[T]) IsZero() (out bool) {
+	if s.Locker == nil {
+		return true
+	}
+	s.RWith(func(v []T) { out = len(v) == 0 })
+	return
+}
+
+// Name returns the name given at construction, empty if unnamed
+func (s Slice[T]) Name() string { return s.name }
+
+// String implements fmt.Stringer, formatting the slice under the read lock.
+// A zero Slice is formatted as an empty slice.
+func (s Slice[T]) String() (out string) {
+	if s.Locker == nil {
+		return withName(s.name, fmt.Sprintf("%v", []T(nil)))
+	}
+	s.RWith(func(v []T) { out = withName(s.name, fmt.Sprintf("%v", v)) })
+	return
+}
+
+// GoString implements fmt.GoStringer, formatting the slice under the read lock
+func (s Slice[T]) GoString() (out string) {
+	if s.Locker == nil {
+		return goStringWithName(s, s.name, []T(nil))
+	}
+	s.RWith(func(v []T) { out = goStringWithName(s, s.name, v) })
+	return
+}
+
+// Retain removes, in place, the elements that do not satisfy the "keep" predicate callback
+func (s *Slice[T]) Retain(keep func(el T) bool) {
+	s.With(func(v *[]T) { *v = slices.DeleteFunc(*v, func(el T) bool { return !keep(el) }) })
+}
+
+//-----------------------------------------------------------------------------
+// Functions for Slice
+// Go methods cannot add constraints to the type parameters of their receiver,
+// so helpers that require a comparable (or otherwise constrained) element type live here.
+
+// SliceInsertBefore inserts el before the first occurrence of target.
+// Returns false if target is not found.
+func SliceInsertBefore[M ISlice[T], T comparable](s M, target, el T) (found bool) {
+	s.With(func(v *[]T) {
+		if i := slices.Index(*v, target); i != -1 {
+			*v = slices.Insert(*v, i, el)
+			found = true
+		}
+	})
+	return
+}
+
+// SliceInsertAfter inserts el after the first occurrence of target.
+// Returns false if target is not found.
+func SliceInsertAfter[M ISlice[T], T comparable](s M, target, el T) (found bool) {
+	s.With(func(v *[]T) {
+		if i := slices.Index(*v, target); i != -1 {
+			*v = slices.Insert(*v, i+1, el)
+			found = true
+		}
+	})
+	return
+}
+
+// SliceContains returns true if the slice contains el
+func SliceContains[M ISlice[T], T comparable](s M, el T) (out bool) {
+	s.RWith(func(v []T) { out = slices.Contains(v, el) })
+	return
+}
+
+// SliceIndexOf returns the index of the first occurrence of el, or -1 if not present
+func SliceIndexOf[M ISlice[T], T comparable](s M, el T) (out int) {
+	s.RWith(func(v []T) { out = slices.Index(v, el) })
+	return
+}
+
+// SliceMap returns a new slice with the result of f applied to each element of the slice
+func SliceMap[M ISlice[T], T, R any](s M, f func(T) R) (out []R) {
+	s.RWith(func(v []T) {
+		out = make([]R, 0, len(v))
+		for _, e := range v {
+			out = append(out, f(e))
+		}
+	})
+	return
+}
+
+// SliceReduce folds the elements of the slice into an accumulator, starting with initial
+func SliceReduce[M ISlice[T], T, A any](s M, initial A, f func(acc A, el T) A) (out A) {
+	out = initial
+	s.RWith(func(v []T) {
+		for _, e := range v {
+			out = f(out, e)
+		}
+	})
+	return
+}
+
+// SliceSort sorts the slice in place in ascending order
+func SliceSort[M ISlice[T], T cmp.Ordered](s M) {
+	s.With(func(v *[]T) { slices.Sort(*v) })
+}
+
+// SliceCount returns the number of elements for which pred returns true
+func SliceCount[M ISlice[T], T any](s M, pred func(T) bool) (out int) {
+	s.RWith(func(v []T) {
+		for _, e := range v {
+			if pred(e) {
+				out++
+			}
+		}
+	})
+	return
+}
+
+// SliceAny returns true if pred returns true for at least one element, false for an empty slice
+func SliceAny[M ISlice[T], T any](s M, pred func(T) bool) (out bool) {
+	s.RWith(func(v []T) { out = slices.ContainsFunc(v, pred) })
+	return
+}
+
+// SliceAll returns true if pred returns true for every element, true for an empty slice
+func SliceAll[M ISlice[T], T any](s M, pred func(T) bool) (out bool) {
+	s.RWith(func(v []T) { out = !slices.ContainsFunc(v, func(e T) bool { return !pred(e) }) })
+	return
+}
+
+// SliceCompareAndSwapAt replaces the element at index i with new if it is equal to old,
+// and returns whether it was replaced. Panics if i is out of range, like Slice.Get.
+func SliceCompareAndSwapAt[M ISlice[T], T comparable](s M, i int, old, new T) (swapped bool) {
+	s.With(func(v *[]T) {
+		if (*v)[i] == old {
+			(*v)[i] = new
+			swapped = true
+		}
+	})
+	return
+}
+
+// SliceMin returns the smallest element of the slice, or false if the slice is empty
+func SliceMin[M ISlice[T], T cmp.Ordered](s M) (out T, ok bool) {
+	s.RWith(func(v []T) {
+		if len(v) > 0 {
+			out, ok = slices.Min(v), true
+		}
+	})
+	return
+}
+
+// SliceMax returns the largest element of the slice, or false if the slice is empty
+func SliceMax[M ISlice[T], T cmp.Ordered](s M) (out T, ok bool) {
+	s.RWith(func(v []T) {
+		if len(v) > 0 {
+			out, ok = slices.Max(v), true
+		}
+	})
+	return
+}
+
+// SliceSum returns the sum of all elements of the slice
+func SliceSum[M ISlice[T], T INumber](s M) (out T) {
+	s.RWith(func(v []T) {
+		for _, e := range v {
+			out += e
+		}
+	})
+	return
+}
+
+// SliceAvg returns the average of all elements of the slice, or 0 if the slice is empty.
+// Elements are summed as float64, so integer slices cannot overflow.
+func SliceAvg[M ISlice[T], T IOrderedNumber](s M) (out float64) {
+	s.RWith(func(v []T) {
+		if len(v) == 0 {
+			return
+		}
+		for _, e := range v {
+			out += float64(e)
+		}
+		out /= float64(len(v))
+	})
+	return
+}
+
+// SliceDedup removes consecutive duplicate elements in place, like slices.Compact
+func SliceDedup[M ISlice[T], T comparable](s M) {
+	s.With(func(v *[]T) { *v = slices.Compact(*v) })
+}
+
+// SliceDedupAll removes all duplicate elements in place, keeping the first occurrence of each
+func SliceDedupAll[M ISlice[T], T comparable](s M) {
+	s.With(func(v *[]T) {
+		seen := make(map[T]struct{}, len(*v))
+		out := (*v)[:0]
+		for _, e := range *v {
+			if _, ok := seen[e]; !ok {
+				seen[e] = struct{}{}
+				out = append(out, e)
+			}
+		}
+		clear((*v)[len(out):])
+		*v = out
+	})
+}
+
+// SliceChunk returns a copy of the slice split into consecutive chunks of at most size elements,
+// only the last chunk can be shorter. Panics if size is less than 1, like slices.Chunk.
+func SliceChunk[M ISlice[T], T any](s M, size int) (out [][]T) {
+	if size < 1 {
+		panic("mtx: SliceChunk size must be at least 1")
+	}
+	s.RWith(func(v []T) {
+		out = make([][]T, 0, (len(v)+size-1)/size)
+		for chunk := range slices.Chunk(slices.Clone(v), size) {
+			out = append(out, chunk)
+		}
+	})
+	return
+}
+
+// TransferSliceElem removes the element at index i of "from" and appends it to "to", under both write locks,
+// so the element is never observable in both slices or in neither. Panics if i is out of range.
+// If "to" is a full BoundedSlice, its oldest element is dropped to make room.
+func TransferSliceElem[T any](from, to ISlice[T], i int) {
+	With2(from, to, func(src, dst *[]T) {
+		el := (*src)[i]
+		*src = slices.Delete(*src, i, i+1)
+		*dst = append(*dst, el)
+	})
+}
+
+// SliceAppendUnique appends the elements of els that are not already present in the slice, in order,
+// and returns how many were appended. Duplicates within els are appended only once.
+// On a BoundedSlice, the result is then trimmed from the front to the bound.
+func SliceAppendUnique[M ISlice[T], T comparable](s M, els ...T) (added int) {
+	s.With(func(v *[]T) {
+		seen := make(map[T]struct{}, len(*v)+len(els))
+		for _, e := range *v {
+			seen[e] = struct{}{}
+		}
+		for _, e := range els {
+			if _, ok := seen[e]; !ok {
+				seen[e] = struct{}{}
+				*v = append(*v, e)
+				added++
+			}
+		}
+	})
+	return
+}
+
 //-----------------------------------------------------------------------------
 // Methods for Number
 
@@ -505,3 +2574,84 @@ func (n *Number[T]) Add(diff T) { n.With(func(v *T) { *v += diff }) }
 
 // Sub subtract "diff" to the protected number
 func (n *Number[T]) Sub(diff T) { n.With(func(v *T) { *v -= diff }) }
+
+// Clone returns a new Number, using the same kind of backend, holding the current value.
+// The clone has its own independent lock, see cloneBackend for the backend-specific state.
+func (n *Number[T]) Clone() Number[T] { return Number[T]{Locker: cloneBackend(n.Locker), name: n.name} }
+
+// Batch applies "ops" to the protected number and stores the result, all in a single locked section
+func (n *Number[T]) Batch(ops func(cur T) T) { n.With(func(v *T) { *v = ops(*v) }) }
+
+// SwapFunc set the number to the result of f applied to the current number, and return the old number
+func (n *Number[T]) SwapFunc(f func(old T) T) (old T) { return swapFunc(n.Locker, f) }
+
+// RLocker same as Mtx.RLocker
+func (n Number[T]) RLocker() sync.Locker { return rLocker(n.Locker) }
+
+// WithRecover same as Mtx.WithRecover
+func (n Number[T]) WithRecover(clb func(v *T)) error { return withRecover(n.Locker, clb) }
+
+// WithTimeout same as Mtx.WithTimeout
+func (n Number[T]) WithTimeout(d time.Duration, clb func(v *T)) bool {
+	return withTimeout(n.Locker, d, clb)
+}
+
+// IsZero reports whether the number is 0, or if the Number itself is the zero value.
+func (n Number[T]) IsZero() (out bool) {
+	if n.Locker == nil {
+		return true
+	}
+	n.RWith(func(v T) { out = v == 0 })
+	return
+}
+
+// Name returns the name given at construction, empty if unnamed
+func (n Number[T]) Name() string { return n.name }
+
+// String implements fmt.Stringer, formatting the number under the read lock.
+// It uses a value receiver so that a Number embedded by value in a struct is also formatted,
+// a zero Number is formatted as 0.
+func (n Number[T]) String() string { return withName(n.name, formatNumber(loadOrZero(n.Locker))) }
+
+// GoString implements fmt.GoStringer, formatting the number under the read lock
+func (n Number[T]) GoString() string { return goStringWithName(n, n.name, loadOrZero(n.Locker)) }
+
+//-----------------------------------------------------------------------------
+// Functions for Number
+
+// NumberClamp sets the protected number to lo if it is below lo, or to hi if it is above hi
+func NumberClamp[T IOrderedNumber](n *Number[T], lo, hi T) {
+	n.With(func(v *T) { *v = min(max(*v, lo), hi) })
+}
+
+// NumberSetMin lowers the protected number to v if it is above v
+func NumberSetMin[T IOrderedNumber](n *Number[T], v T) {
+	n.With(func(cur *T) { *cur = min(*cur, v) })
+}
+
+// NumberSetMax raises the protected number to v if it is below v
+func NumberSetMax[T IOrderedNumber](n *Number[T], v T) {
+	n.With(func(cur *T) { *cur = max(*cur, v) })
+}
+
+// NumberStoreIfGreater stores v if it is greater than the protected number, and returns whether it was stored
+func NumberStoreIfGreater[T IOrderedNumber](n *Number[T], v T) (stored bool) {
+	n.With(func(cur *T) {
+		if v > *cur {
+			*cur = v
+			stored = true
+		}
+	})
+	return
+}
+
+// NumberStoreIfLess stores v if it is less than the protected number, and returns whether it was stored
+func NumberStoreIfLess[T IOrderedNumber](n *Number[T], v T) (stored bool) {
+	n.With(func(cur *T) {
+		if v < *cur {
+			*cur = v
+			stored = true
+		}
+	})
+	return
+}
@@ -23,10 +23,18 @@
 package mtx
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"maps"
+	"math"
+	"math/rand/v2"
 	"slices"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestMtx_LockUnlock(t *testing.T) {
@@ -210,6 +218,26 @@ func TestMap_Each(t *testing.T) {
 	assert.Equal(t, []string{"a_1", "b_2", "c_3"}, arr)
 }
 
+func TestMap_EachUntil(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	visited := 0
+	m.EachUntil(func(k string, v int) bool { visited++; return false })
+	assert.Equal(t, 1, visited)
+	visited = 0
+	m.EachUntil(func(k string, v int) bool { visited++; return true })
+	assert.Equal(t, 3, visited)
+}
+
+func TestSlice_EachUntil(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3, 4})
+	arr := make([]int, 0)
+	s.EachUntil(func(el int) bool { arr = append(arr, el); return el < 2 })
+	assert.Equal(t, []int{1, 2}, arr)
+	arr = arr[:0]
+	s.EachUntil(func(el int) bool { arr = append(arr, el); return true })
+	assert.Equal(t, []int{1, 2, 3, 4}, arr)
+}
+
 func TestMap_Clone(t *testing.T) {
 	m := NewMap[string, int](nil)
 	m.Store(map[string]int{"a": 1, "b": 2, "c": 3})
@@ -448,3 +476,1809 @@ func TestNumber(t *testing.T) {
 	n4.Sub(5)
 	assert.Equal(t, uint64(5), n4.Load())
 }
+
+func TestSlice_SwapContents(t *testing.T) {
+	s1 := NewSlice([]int{1, 2, 3})
+	s2 := NewRWSlice([]int{4, 5})
+	s1.SwapContents(&s2)
+	assert.Equal(t, []int{4, 5}, s1.Load())
+	assert.Equal(t, []int{1, 2, 3}, s2.Load())
+	s2.SwapContents(&s1)
+	assert.Equal(t, []int{1, 2, 3}, s1.Load())
+	assert.Equal(t, []int{4, 5}, s2.Load())
+	s1.SwapContents(&s1)
+	assert.Equal(t, []int{1, 2, 3}, s1.Load())
+
+	b := NewBoundedSlicePtr[int](2)
+	b.Append(8, 9)
+	b.SwapContents(&s1)
+	assert.Equal(t, []int{2, 3}, b.Load())
+	assert.Equal(t, []int{8, 9}, s1.Load())
+	s1.Store([]int{4, 5, 6})
+	s1.SwapContents(&b.Slice)
+	assert.Equal(t, []int{5, 6}, b.Load())
+	assert.Equal(t, []int{2, 3}, s1.Load())
+}
+
+func TestSlice_Drain(t *testing.T) {
+	s := NewRWSlicePtr([]int{})
+	const n = 10_000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range n {
+			s.Append(i)
+		}
+	}()
+	seen := make(map[int]int, n)
+	drain := func() {
+		for _, el := range s.Drain() {
+			seen[el]++
+		}
+	}
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		drain()
+	}
+	drain()
+	assert.Equal(t, n, len(seen))
+	for i := range n {
+		assert.Equal(t, 1, seen[i])
+	}
+	assert.Equal(t, []int{}, s.Drain())
+}
+
+func TestMap_Drain(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, m.Drain())
+	assert.Equal(t, 0, m.Len())
+	m.Insert("c", 3)
+	assert.Equal(t, map[string]int{"c": 3}, m.Drain())
+	assert.Equal(t, map[string]int{}, m.Drain())
+}
+
+func TestSlice_Set(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3})
+	s.Set(1, 5)
+	assert.Equal(t, []int{1, 5, 3}, s.Load())
+	assert.Panics(t, func() { s.Set(3, 0) })
+	assert.Equal(t, []int{1, 5, 3}, s.Load())
+}
+
+func TestSlice_Move(t *testing.T) {
+	s := NewRWSlice([]string{"a", "b", "c", "d"})
+	s.Move(0, 2)
+	assert.Equal(t, []string{"b", "c", "a", "d"}, s.Load())
+	s.Move(3, 0)
+	assert.Equal(t, []string{"d", "b", "c", "a"}, s.Load())
+	s.Move(1, 1)
+	assert.Equal(t, []string{"d", "b", "c", "a"}, s.Load())
+	assert.Panics(t, func() { s.Move(0, 4) })
+	assert.Panics(t, func() { s.Move(-1, 0) })
+	assert.Equal(t, []string{"d", "b", "c", "a"}, s.Load())
+}
+
+func TestSlice_Rotate(t *testing.T) {
+	s := NewRWSlice([]int{1, 2, 3, 4, 5})
+	s.Rotate(2)
+	assert.Equal(t, []int{3, 4, 5, 1, 2}, s.Load())
+	s.Rotate(-2)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, s.Load())
+	s.Rotate(7)
+	assert.Equal(t, []int{3, 4, 5, 1, 2}, s.Load())
+	s.Rotate(-12)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, s.Load())
+	s.Rotate(5)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, s.Load())
+	empty := NewSlice([]int{})
+	empty.Rotate(3)
+	assert.Equal(t, []int{}, empty.Load())
+}
+
+func TestSlice_SwapAt(t *testing.T) {
+	s := NewRWSlice([]int{1, 2, 3})
+	s.SwapAt(0, 2)
+	assert.Equal(t, []int{3, 2, 1}, s.Load())
+	s.SwapAt(1, 1)
+	assert.Equal(t, []int{3, 2, 1}, s.Load())
+	assert.Panics(t, func() { s.SwapAt(0, 3) })
+	assert.Panics(t, func() { s.SwapAt(-1, 0) })
+	assert.Equal(t, []int{3, 2, 1}, s.Load())
+}
+
+func TestNumber_Batch(t *testing.T) {
+	n := NewRWNumber(10)
+	n.Batch(func(cur int) int {
+		cur += 5
+		cur *= 2
+		return min(cur, 25)
+	})
+	assert.Equal(t, 25, n.Load())
+}
+
+func TestMap_Update(t *testing.T) {
+	m := NewRWMap[string, int](nil)
+	incr := func(old int, existed bool) int {
+		if !existed {
+			return 1
+		}
+		return old + 1
+	}
+	m.Update("a", incr)
+	assert.Equal(t, 1, first(m.Get("a")))
+	m.Update("a", incr)
+	assert.Equal(t, 2, first(m.Get("a")))
+	m.Update("b", func(old int, existed bool) int {
+		assert.False(t, existed)
+		assert.Equal(t, 0, old)
+		return 10
+	})
+	assert.Equal(t, 10, first(m.Get("b")))
+}
+
+func TestMap_ComputeIfAbsent(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1})
+	calls := 0
+	f := func(k string) int { calls++; return len(k) * 10 }
+	assert.Equal(t, 1, m.ComputeIfAbsent("a", f))
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, 20, m.ComputeIfAbsent("bb", f))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[string]int{"a": 1, "bb": 20}, m.Load())
+}
+
+func TestMap_ComputeIfPresent(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2})
+	v, ok := m.ComputeIfPresent("a", func(k string, v int) (int, bool) { return v + 10, true })
+	assert.True(t, ok)
+	assert.Equal(t, 11, v)
+	v, ok = m.ComputeIfPresent("b", func(k string, v int) (int, bool) { return 0, false })
+	assert.False(t, ok)
+	assert.Equal(t, 0, v)
+	called := false
+	_, ok = m.ComputeIfPresent("c", func(k string, v int) (int, bool) { called = true; return 3, true })
+	assert.False(t, ok)
+	assert.False(t, called)
+	assert.Equal(t, map[string]int{"a": 11}, m.Load())
+}
+
+func TestMap_ApproxLen(t *testing.T) {
+	m := NewRWMapPtr(map[int]int{1: 1})
+	assert.Equal(t, 1, m.ApproxLen())
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Insert(i, i)
+			if i%3 == 0 {
+				m.Delete(i)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, m.Len(), m.ApproxLen())
+	m.InsertMany(map[int]int{200: 1, 201: 2})
+	m.Remove(1)
+	_, _, _ = m.Pop()
+	assert.Equal(t, m.Len(), m.ApproxLen())
+	m.Store(map[int]int{1: 1, 2: 2})
+	assert.Equal(t, 2, m.ApproxLen())
+	m.Clear()
+	assert.Equal(t, 0, m.ApproxLen())
+	assert.Equal(t, 0, (&Map[int, int]{}).ApproxLen())
+	assert.Equal(t, 1, (&Map[int, int]{Locker: NewMtxPtr(map[int]int{1: 1})}).ApproxLen())
+}
+
+func TestMap_ApproxLen_DirectWrites(t *testing.T) {
+	m := NewMapPtr(map[string]int{})
+	m.Lock()
+	(*m.GetPointer())["a"] = 1
+	(*m.GetPointer())["b"] = 2
+	m.Unlock()
+	assert.Equal(t, 0, m.ApproxLen()) // not accounted for yet
+	m.Insert("c", 3)
+	assert.Equal(t, 3, m.Len())
+	assert.Equal(t, m.Len(), m.ApproxLen())
+}
+
+func TestMap_GetMany(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	assert.Equal(t, map[string]int{"a": 1, "c": 3}, m.GetMany([]string{"a", "c", "x"}))
+	assert.Equal(t, map[string]int{}, m.GetMany([]string{"x", "y"}))
+	assert.Equal(t, map[string]int{}, m.GetMany(nil))
+}
+
+func TestMap_ReplaceAll(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	m.ReplaceAll(func(k string, v int) int { return v * 2 })
+	assert.Equal(t, map[string]int{"a": 2, "b": 4, "c": 6}, m.Load())
+	assert.Equal(t, 3, m.Len())
+}
+
+func TestMap_Rebuild(t *testing.T) {
+	m := NewRWMapPtr(map[int]int{})
+	for i := range 100 {
+		m.Insert(i, 0)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			m.RWith(func(mm map[int]int) {
+				for _, v := range mm {
+					if v != mm[0] || len(mm) != 100 {
+						t.Errorf("partial rebuild observed")
+						return
+					}
+				}
+			})
+		}
+	}()
+	for gen := 1; gen <= 10; gen++ {
+		m.Rebuild(func(old map[int]int) map[int]int {
+			old[0] = -1 // the clone can be mutated freely
+			out := make(map[int]int, len(old))
+			for k := range old {
+				out[k] = gen
+			}
+			return out
+		})
+	}
+	<-done
+	assert.Equal(t, 10, first(m.Get(0)))
+	m.Rebuild(func(map[int]int) map[int]int { return nil })
+	assert.Equal(t, map[int]int{}, m.Load())
+}
+
+func TestMap_Merge(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2})
+	m.Merge(map[string]int{"b": 3, "c": 4})
+	assert.Equal(t, map[string]int{"a": 1, "b": 3, "c": 4}, m.Load())
+}
+
+func TestMap_MergeFunc(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 5})
+	m.MergeFunc(map[string]int{"a": 3, "b": 2, "c": 4}, func(k string, existing, incoming int) int {
+		return max(existing, incoming)
+	})
+	assert.Equal(t, map[string]int{"a": 3, "b": 5, "c": 4}, m.Load())
+}
+
+func TestMap_InsertMany(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1})
+	m.InsertMany(map[string]int{"a": 2, "b": 3})
+	assert.Equal(t, map[string]int{"a": 2, "b": 3}, m.Load())
+	m.InsertPairs(Pair[string, int]{"c", 4}, Pair[string, int]{"c", 5}, Pair[string, int]{"d", 6})
+	assert.Equal(t, map[string]int{"a": 2, "b": 3, "c": 5, "d": 6}, m.Load())
+}
+
+func TestMap_InsertMany_Atomic(t *testing.T) {
+	m := NewRWMapPtr(map[int]int{})
+	keys := make(map[int]int, 100)
+	pairs := make([]Pair[int, int], 100)
+	for i := range 100 {
+		keys[i] = 1
+		pairs[i] = Pair[int, int]{i, 2}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			m.RWith(func(mm map[int]int) {
+				n := len(mm)
+				if n != 0 && n != 100 {
+					t.Errorf("partial insert observed: %d entries", n)
+				}
+				for _, v := range mm {
+					if v != mm[0] {
+						t.Errorf("mixed values observed")
+						return
+					}
+				}
+			})
+		}
+	}()
+	m.InsertMany(keys)
+	m.InsertPairs(pairs...)
+	<-done
+	assert.Equal(t, 100, m.Len())
+	assert.Equal(t, 2, first(m.Get(99)))
+}
+
+func TestSliceInsertBefore(t *testing.T) {
+	s := NewSlicePtr([]string{"a", "c", "c"})
+	assert.True(t, SliceInsertBefore(s, "c", "b"))
+	assert.Equal(t, []string{"a", "b", "c", "c"}, s.Load())
+	assert.True(t, SliceInsertBefore(s, "a", "z"))
+	assert.Equal(t, []string{"z", "a", "b", "c", "c"}, s.Load())
+	assert.False(t, SliceInsertBefore(s, "x", "y"))
+	assert.Equal(t, []string{"z", "a", "b", "c", "c"}, s.Load())
+}
+
+func TestSliceInsertAfter(t *testing.T) {
+	s := NewRWSlicePtr([]string{"a", "c", "c"})
+	assert.True(t, SliceInsertAfter(s, "c", "d"))
+	assert.Equal(t, []string{"a", "c", "d", "c"}, s.Load())
+	assert.True(t, SliceInsertAfter(s, "a", "b"))
+	assert.Equal(t, []string{"a", "b", "c", "d", "c"}, s.Load())
+	assert.False(t, SliceInsertAfter(s, "x", "y"))
+	assert.Equal(t, []string{"a", "b", "c", "d", "c"}, s.Load())
+}
+
+func TestSliceContains(t *testing.T) {
+	s := NewRWSlicePtr([]int{1, 2, 2, 3})
+	assert.True(t, SliceContains(s, 1))
+	assert.True(t, SliceContains(s, 2))
+	assert.False(t, SliceContains(s, 4))
+	assert.False(t, SliceContains(NewSlicePtr[int](nil), 1))
+}
+
+func TestSliceIndexOf(t *testing.T) {
+	s := NewSlicePtr([]int{1, 2, 2, 3})
+	assert.Equal(t, 0, SliceIndexOf(s, 1))
+	assert.Equal(t, 1, SliceIndexOf(s, 2))
+	assert.Equal(t, 3, SliceIndexOf(s, 3))
+	assert.Equal(t, -1, SliceIndexOf(s, 4))
+}
+
+func TestCOWMtx_LoadShared(t *testing.T) {
+	type report struct{ A, B int }
+	m := NewCOWMtxPtr(report{A: 1, B: 2})
+	shared := m.LoadShared()
+	assert.Equal(t, report{A: 1, B: 2}, *shared)
+	assert.Same(t, shared, m.LoadShared())
+	m.With(func(v *report) { v.A = 3 })
+	assert.Equal(t, report{A: 1, B: 2}, *shared)
+	assert.Equal(t, report{A: 3, B: 2}, *m.LoadShared())
+	m.Store(report{A: 4})
+	assert.Equal(t, report{A: 4}, *m.LoadShared())
+	assert.Equal(t, report{A: 4}, m.Swap(report{A: 5}))
+	assert.Equal(t, report{A: 5}, *m.LoadShared())
+	m.Lock()
+	m.GetPointer().B = 6
+	m.Unlock()
+	assert.Equal(t, report{A: 5, B: 6}, *m.LoadShared())
+}
+
+func TestMtx_LoadShared(t *testing.T) {
+	m := NewRWMtx(1)
+	shared := m.LoadShared()
+	m.Store(2)
+	assert.Equal(t, 1, *shared)
+	assert.Equal(t, 2, *m.LoadShared())
+}
+
+func TestMap_Transaction(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 2})
+	m.Transaction(func(raw map[string]int) {
+		if raw["a"] < raw["b"] {
+			raw["c"] = raw["a"] + raw["b"]
+			delete(raw, "a")
+		}
+	})
+	assert.Equal(t, map[string]int{"b": 2, "c": 3}, m.Load())
+}
+
+func TestSliceMap(t *testing.T) {
+	s := NewRWSlicePtr([]int{1, 2, 3})
+	out := SliceMap(s, func(el int) string { return fmt.Sprintf("E%d", el) })
+	assert.Equal(t, []string{"E1", "E2", "E3"}, out)
+	assert.Equal(t, []int{1, 2, 3}, s.Load())
+	assert.Equal(t, []string{}, SliceMap(NewSlicePtr[int](nil), strconv.Itoa))
+}
+
+func TestSliceReduce(t *testing.T) {
+	s := NewSlicePtr([]int{1, 2, 3, 4})
+	sum := SliceReduce(s, 0, func(acc, el int) int { return acc + el })
+	assert.Equal(t, 10, sum)
+
+	words := NewRWSlicePtr([]string{"a", "b", "a", "c", "a"})
+	histogram := SliceReduce(words, map[string]int{}, func(acc map[string]int, el string) map[string]int {
+		acc[el]++
+		return acc
+	})
+	assert.Equal(t, map[string]int{"a": 3, "b": 1, "c": 1}, histogram)
+}
+
+func TestSlice_Resize(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3})
+	s.Resize(5, -1)
+	assert.Equal(t, []int{1, 2, 3, -1, -1}, s.Load())
+	s.Resize(2, -1)
+	assert.Equal(t, []int{1, 2}, s.Load())
+	assert.Equal(t, []int{1, 2, 0, 0, 0}, s.Load()[:5])
+	s.Resize(2, -1)
+	assert.Equal(t, []int{1, 2}, s.Load())
+	s.Resize(0, -1)
+	assert.Equal(t, []int{}, s.Load())
+}
+
+func TestSlice_CopyInto(t *testing.T) {
+	s := NewRWSlice([]int{1, 2, 3})
+	small := make([]int, 2)
+	assert.Equal(t, 2, s.CopyInto(small))
+	assert.Equal(t, []int{1, 2}, small)
+	equal := make([]int, 3)
+	assert.Equal(t, 3, s.CopyInto(equal))
+	assert.Equal(t, []int{1, 2, 3}, equal)
+	large := []int{9, 9, 9, 9, 9}
+	assert.Equal(t, 3, s.CopyInto(large))
+	assert.Equal(t, []int{1, 2, 3, 9, 9}, large)
+	assert.Equal(t, 0, s.CopyInto(nil))
+}
+
+func TestSlice_FilterInto(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3, 4, 5, 6})
+	isEven := func(el int) bool { return el%2 == 0 }
+	buf := make([]int, 0, 8)
+	out := s.FilterInto(buf, isEven)
+	assert.Equal(t, []int{2, 4, 6}, out)
+	assert.Same(t, &buf[:1][0], &out[0])
+	out = s.FilterInto(out, func(el int) bool { return el > 4 })
+	assert.Equal(t, []int{5, 6}, out)
+	assert.Same(t, &buf[:1][0], &out[0])
+	assert.Equal(t, []int{2, 4, 6}, s.FilterInto(nil, isEven))
+}
+
+func TestSlice_Cap(t *testing.T) {
+	s := NewSlice(make([]int, 0, 2))
+	assert.Equal(t, 2, s.Cap())
+	s.Append(1, 2)
+	assert.Equal(t, 2, s.Cap())
+	s.Append(3)
+	assert.Greater(t, s.Cap(), 2)
+	assert.GreaterOrEqual(t, s.Cap(), s.Len())
+}
+
+func TestSlice_GrowTruncate(t *testing.T) {
+	s := NewRWSlice([]int{1, 2, 3})
+	s.Grow(100)
+	s.RWith(func(v []int) { assert.GreaterOrEqual(t, cap(v), 103) })
+	assert.Equal(t, []int{1, 2, 3}, s.Load())
+	s.Truncate(1)
+	assert.Equal(t, []int{1}, s.Load())
+	assert.Equal(t, []int{1, 0, 0}, s.Load()[:3])
+	assert.Panics(t, func() { s.Truncate(2) })
+	s.Truncate(0)
+	assert.Equal(t, []int{}, s.Load())
+}
+
+func TestSlice_SortFunc(t *testing.T) {
+	s := NewRWSlice([]string{"bb", "a", "ccc"})
+	byLen := func(a, b string) int { return len(a) - len(b) }
+	s.SortFunc(byLen)
+	assert.Equal(t, []string{"a", "bb", "ccc"}, s.Load())
+	s.SortFunc(byLen)
+	assert.Equal(t, []string{"a", "bb", "ccc"}, s.Load())
+	s.SortFunc(func(a, b string) int { return byLen(b, a) })
+	assert.Equal(t, []string{"ccc", "bb", "a"}, s.Load())
+}
+
+func TestSliceSort(t *testing.T) {
+	s := NewSlicePtr([]int{3, 1, 2})
+	SliceSort(s)
+	assert.Equal(t, []int{1, 2, 3}, s.Load())
+	SliceSort(s)
+	assert.Equal(t, []int{1, 2, 3}, s.Load())
+}
+
+func TestSliceCount(t *testing.T) {
+	s := NewSlicePtr([]int{1, 2, 3, 4, 6})
+	assert.Equal(t, 3, SliceCount(s, func(el int) bool { return el%2 == 0 }))
+	assert.Equal(t, 0, SliceCount(NewSlicePtr([]int{}), func(el int) bool { return true }))
+}
+
+func TestMapCount(t *testing.T) {
+	m := NewRWMapPtr(map[string]int{"a": 1, "b": 20, "c": 30})
+	assert.Equal(t, 2, MapCount(m, func(k string, v int) bool { return v > 10 }))
+}
+
+func TestSliceAnyAll(t *testing.T) {
+	isEven := func(el int) bool { return el%2 == 0 }
+	empty := NewSlicePtr([]int{})
+	assert.False(t, SliceAny(empty, isEven))
+	assert.True(t, SliceAll(empty, isEven))
+	evens := NewRWSlicePtr([]int{2, 4})
+	assert.True(t, SliceAny(evens, isEven))
+	assert.True(t, SliceAll(evens, isEven))
+	odds := NewSlicePtr([]int{1, 3})
+	assert.False(t, SliceAny(odds, isEven))
+	assert.False(t, SliceAll(odds, isEven))
+	mixed := NewSlicePtr([]int{1, 2})
+	assert.True(t, SliceAny(mixed, isEven))
+	assert.False(t, SliceAll(mixed, isEven))
+}
+
+func TestMapAnyAll(t *testing.T) {
+	isBig := func(k string, v int) bool { return v > 10 }
+	empty := NewMapPtr(map[string]int{})
+	assert.False(t, MapAny(empty, isBig))
+	assert.True(t, MapAll(empty, isBig))
+	big := NewRWMapPtr(map[string]int{"a": 20, "b": 30})
+	assert.True(t, MapAny(big, isBig))
+	assert.True(t, MapAll(big, isBig))
+	small := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	assert.False(t, MapAny(small, isBig))
+	assert.False(t, MapAll(small, isBig))
+	mixed := NewMapPtr(map[string]int{"a": 1, "b": 20})
+	assert.True(t, MapAny(mixed, isBig))
+	assert.False(t, MapAll(mixed, isBig))
+}
+
+func TestSliceCompareAndSwapAt(t *testing.T) {
+	s := NewRWSlicePtr([]string{"", "b"})
+	assert.True(t, SliceCompareAndSwapAt(s, 0, "", "a"))
+	assert.False(t, SliceCompareAndSwapAt(s, 0, "", "z"))
+	assert.False(t, SliceCompareAndSwapAt(s, 1, "x", "z"))
+	assert.Equal(t, []string{"a", "b"}, s.Load())
+	assert.Panics(t, func() { SliceCompareAndSwapAt(s, 2, "", "c") })
+}
+
+func TestSliceMinMax(t *testing.T) {
+	s := NewRWSlicePtr([]int{3, 1, 4, 1, 5})
+	assert.Equal(t, 1, first(SliceMin(s)))
+	assert.Equal(t, 5, first(SliceMax(s)))
+	_, ok := SliceMin(NewSlicePtr([]string{}))
+	assert.False(t, ok)
+	_, ok = SliceMax(NewSlicePtr([]string{}))
+	assert.False(t, ok)
+	assert.Equal(t, "b", first(SliceMax(NewSlicePtr([]string{"a", "b"}))))
+}
+
+func TestSliceSumAvg(t *testing.T) {
+	ints := NewSlicePtr([]int{1, 2, 3, 4})
+	assert.Equal(t, 10, SliceSum(ints))
+	assert.Equal(t, 2.5, SliceAvg(ints))
+	floats := NewRWSlicePtr([]float64{0.5, 1.5})
+	assert.Equal(t, 2.0, SliceSum(floats))
+	assert.Equal(t, 1.0, SliceAvg(floats))
+	empty := NewSlicePtr([]int{})
+	assert.Equal(t, 0, SliceSum(empty))
+	assert.Equal(t, 0.0, SliceAvg(empty))
+}
+
+func TestSliceDedup(t *testing.T) {
+	s := NewSlicePtr([]int{1, 1, 2, 1, 3, 3})
+	SliceDedup(s)
+	assert.Equal(t, []int{1, 2, 1, 3}, s.Load())
+	s.Store([]int{1, 1, 2, 1, 3, 3, 2})
+	SliceDedupAll(s)
+	assert.Equal(t, []int{1, 2, 3}, s.Load())
+	assert.Equal(t, []int{1, 2, 3, 0, 0, 0, 0}, s.Load()[:7])
+}
+
+func TestSliceChunk(t *testing.T) {
+	s := NewSlicePtr([]int{1, 2, 3, 4})
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}}, SliceChunk(s, 2))
+	assert.Equal(t, [][]int{{1, 2, 3}, {4}}, SliceChunk(s, 3))
+	assert.Equal(t, [][]int{{1, 2, 3, 4}}, SliceChunk(s, 10))
+	assert.Equal(t, [][]int{}, SliceChunk(NewSlicePtr([]int{}), 2))
+	assert.Panics(t, func() { SliceChunk(s, 0) })
+	chunks := SliceChunk(s, 2)
+	chunks[0][0] = 9
+	chunks[0] = append(chunks[0], 9)
+	assert.Equal(t, []int{1, 2, 3, 4}, s.Load())
+	assert.Equal(t, []int{3, 4}, chunks[1])
+}
+
+func TestTransferSliceElem(t *testing.T) {
+	from, to := NewSlicePtr([]int{1, 2, 3}), NewRWSlicePtr([]int{})
+	TransferSliceElem(from, to, 1)
+	assert.Equal(t, []int{1, 3}, from.Load())
+	assert.Equal(t, []int{2}, to.Load())
+	assert.Panics(t, func() { TransferSliceElem(from, to, 5) })
+
+	// concurrent readers never see an element in both or neither slice
+	src, dst := NewSlicePtr(make([]int, 100)), NewSlicePtr([]int{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			TransferSliceElem(src, dst, 0)
+		}
+	}()
+	for range 100 {
+		RWith2(src, dst, func(a, b []int) { assert.Equal(t, 100, len(a)+len(b)) })
+	}
+	<-done
+	assert.Equal(t, 100, dst.Len())
+
+	bounded := NewBoundedSlicePtr[int](2)
+	bounded.Append(7, 8)
+	TransferSliceElem[int](from, bounded, 0)
+	assert.Equal(t, []int{3}, from.Load())
+	assert.Equal(t, []int{8, 1}, bounded.Load())
+}
+
+func TestTransferMapEntry(t *testing.T) {
+	from, to := NewMapPtr(map[string]int{"a": 1, "b": 2}), NewRWMapPtr(map[string]int{})
+	assert.True(t, TransferMapEntry(from, to, "a"))
+	assert.False(t, TransferMapEntry(from, to, "x"))
+	assert.Equal(t, map[string]int{"b": 2}, from.Load())
+	assert.Equal(t, map[string]int{"a": 1}, to.Load())
+	assert.Equal(t, 1, to.ApproxLen())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			TransferMapEntry(from, to, "b")
+			TransferMapEntry(to, from, "b")
+		}
+	}()
+	for range 100 {
+		RWith2(from, to, func(a, b map[string]int) { assert.Equal(t, 2, len(a)+len(b)) })
+	}
+	<-done
+}
+
+func TestSliceAppendUnique(t *testing.T) {
+	s := NewSlicePtr([]string{"a", "b"})
+	assert.Equal(t, 2, SliceAppendUnique(s, "b", "c", "a", "d", "c"))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, s.Load())
+	assert.Equal(t, 0, SliceAppendUnique(s, "a"))
+	assert.Equal(t, 0, SliceAppendUnique(s))
+
+	b := NewBoundedSlicePtr[string](3)
+	b.Append("a", "b", "c")
+	assert.Equal(t, 2, SliceAppendUnique(b, "c", "d", "e"))
+	assert.Equal(t, []string{"c", "d", "e"}, b.Load())
+}
+
+func TestTotal(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	s1 := NewSlicePtr([]int{1, 2, 3})
+	s2 := NewRWSlicePtr([]string{"a"})
+	assert.Equal(t, 6, Total(m, s1, s2))
+	assert.Equal(t, 0, Total())
+}
+
+func TestSlice_UpdateEach(t *testing.T) {
+	s := NewRWSlice([]int{10, 10, 10})
+	s.UpdateEach(func(i int, el int) int { return el * (i + 1) })
+	assert.Equal(t, []int{10, 20, 30}, s.Load())
+}
+
+func TestSlice_FirstLastPeek(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3})
+	el, ok := s.First()
+	assert.True(t, ok)
+	assert.Equal(t, 1, el)
+	el, ok = s.Last()
+	assert.True(t, ok)
+	assert.Equal(t, 3, el)
+	el, ok = s.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 3, el)
+	assert.Equal(t, []int{1, 2, 3}, s.Load())
+}
+
+func TestSlice_FirstLastPeek_Empty(t *testing.T) {
+	s := NewRWSlice[int](nil)
+	el, ok := s.First()
+	assert.False(t, ok)
+	assert.Equal(t, 0, el)
+	el, ok = s.Last()
+	assert.False(t, ok)
+	assert.Equal(t, 0, el)
+	el, ok = s.Peek()
+	assert.False(t, ok)
+	assert.Equal(t, 0, el)
+}
+
+func TestSlice_PopOkShiftOk(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3})
+	el, ok := s.PopOk()
+	assert.True(t, ok)
+	assert.Equal(t, 3, el)
+	el, ok = s.ShiftOk()
+	assert.True(t, ok)
+	assert.Equal(t, 1, el)
+	assert.Equal(t, []int{2}, s.Load())
+}
+
+func TestSlice_PopNShiftN(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3, 4, 5})
+	assert.Equal(t, []int{1, 2}, s.ShiftN(2))
+	assert.Equal(t, []int{4, 5}, s.PopN(2))
+	assert.Equal(t, []int{3}, s.Load())
+	assert.Equal(t, []int{3}, s.PopN(10))
+	assert.Equal(t, []int{}, s.ShiftN(10))
+	assert.Equal(t, []int{}, s.PopN(-1))
+	s.Store([]int{1, 2, 3})
+	assert.Equal(t, []int{1, 2, 3}, s.ShiftN(3))
+	s.Store([]int{1, 2, 3})
+	assert.Equal(t, []int{1, 2, 3}, s.PopN(3))
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSlice_PopOkShiftOk_Empty(t *testing.T) {
+	s := NewRWSlice([]int{})
+	el, ok := s.PopOk()
+	assert.False(t, ok)
+	assert.Equal(t, 0, el)
+	el, ok = s.ShiftOk()
+	assert.False(t, ok)
+	assert.Equal(t, 0, el)
+	assert.Equal(t, []int{}, s.Load())
+}
+
+func TestMap_EachParallel(t *testing.T) {
+	m := NewRWMap[int, int](nil)
+	for i := 0; i < 100; i++ {
+		m.Insert(i, i*2)
+	}
+	sum := NewNumber(0)
+	m.EachParallel(4, func(k, v int) {
+		m.Insert(k, v+1) // the map lock is not held while the callback runs
+		sum.Add(v)
+	})
+	assert.Equal(t, 9900, sum.Load())
+	assert.Equal(t, 11, first(m.Get(5)))
+	m.EachParallel(0, func(k, v int) { sum.Sub(v) })
+	assert.Equal(t, -100, sum.Load())
+}
+
+func TestIsZero(t *testing.T) {
+	assert.True(t, NewMtx(0).IsZero())
+	assert.False(t, NewMtx("a").IsZero())
+	assert.True(t, NewMtx[error](nil).IsZero())
+	assert.True(t, Mtx[int]{}.IsZero())
+	assert.True(t, NewMap[string, int](nil).IsZero())
+	assert.False(t, NewMap(map[string]int{"a": 0}).IsZero())
+	assert.True(t, NewRWSlice([]int{}).IsZero())
+	assert.False(t, NewSlice([]int{0}).IsZero())
+	assert.True(t, NewNumber(0.0).IsZero())
+	assert.False(t, NewNumber(1).IsZero())
+
+	type host struct {
+		M Map[string, int] `json:"m,omitzero"`
+		N Number[int]      `json:"n,omitzero"`
+		S Slice[int]       `json:"s,omitzero"`
+		V Mtx[string]      `json:"v,omitzero"`
+	}
+	out, err := json.Marshal(host{M: NewMap[string, int](nil), N: NewNumber(0), S: NewSlice([]int{}), V: NewMtx("")})
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(out))
+}
+
+func TestNumber_String(t *testing.T) {
+	type stats struct {
+		Requests Number[int64]
+		Ratio    Number[float32]
+		Bytes    *Number[uint8]
+		Signal   Number[complex128]
+	}
+	s := stats{
+		Requests: NewNumber(int64(-42)),
+		Ratio:    NewRWNumber(float32(0.1)),
+		Bytes:    NewNumberPtr(uint8(255)),
+		Signal:   NewNumber(complex(1, 2)),
+	}
+	assert.Equal(t, "-42", s.Requests.String())
+	assert.Equal(t, "{-42 0.1 255 (1+2i)}", fmt.Sprintf("%v", s))
+	assert.Equal(t, "{0 0 <nil> (0+0i)}", fmt.Sprintf("%v", stats{}))
+}
+
+func TestMap_Iter(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, maps.Collect(m.Iter()))
+	count := 0
+	for range m.Iter() {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+	m.Insert("d", 4) // lock is released after breaking out of the loop
+	assert.Equal(t, 4, m.Len())
+}
+
+func TestSlice_Iter(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3})
+	assert.Equal(t, []int{1, 2, 3}, slices.Collect(s.Iter()))
+	for el := range s.Iter() {
+		if el == 2 {
+			break
+		}
+	}
+	s.Append(4)
+	assert.Equal(t, []int{1, 2, 3, 4}, s.Load())
+}
+
+func TestSlice_FirstWhere(t *testing.T) {
+	s := NewRWSlice([]int{1, 3, 4, 6})
+	calls := 0
+	el, ok := s.FirstWhere(func(el int) bool {
+		calls++
+		return el%2 == 0
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 4, el)
+	assert.Equal(t, 3, calls)
+	el, ok = s.FirstWhere(func(el int) bool { return el > 10 })
+	assert.False(t, ok)
+	assert.Equal(t, 0, el)
+}
+
+func TestMap_IterSnapshot(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	visited := 0
+	for k := range m.IterSnapshot() {
+		visited++
+		m.Delete(k)
+	}
+	assert.Equal(t, 3, visited)
+	assert.True(t, m.IsEmpty())
+}
+
+func TestSlice_IterSnapshot(t *testing.T) {
+	s := NewSlice([]int{1, 2, 3})
+	for el := range s.IterSnapshot() {
+		s.Append(el * 10)
+	}
+	assert.Equal(t, []int{1, 2, 3, 10, 20, 30}, s.Load())
+	assert.Equal(t, []int{1, 2, 3, 10, 20, 30}, slices.Collect(s.IterSnapshot()))
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "42", NewMtx(42).String())
+	assert.Equal(t, "hello", fmt.Sprintf("%v", NewRWMtx("hello")))
+	assert.Equal(t, "hello", fmt.Sprintf("%v", NewMtxPtr("hello")))
+	assert.Equal(t, "42", fmt.Sprintf("%v", NewMtx(NewRWMtx(42))))
+	assert.Equal(t, "7", fmt.Sprintf("%v", NewNumber(7)))
+	assert.Equal(t, "map[a:1 b:2]", fmt.Sprintf("%v", NewMap(map[string]int{"b": 2, "a": 1})))
+	assert.Equal(t, "[a b]", fmt.Sprintf("%v", NewRWSlice([]string{"a", "b"})))
+	assert.Equal(t, "0", Mtx[int]{}.String())
+	assert.Equal(t, "map[]", Map[string, int]{}.String())
+	assert.Equal(t, "[]", fmt.Sprintf("%v", Slice[int]{}))
+}
+
+func TestSpinMtx(t *testing.T) {
+	m := NewSpinMtxPtr(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.With(func(v *int) { *v++ })
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1000, m.Load())
+}
+
+func benchmarkContended(b *testing.B, m *Mtx[int]) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.With(func(v *int) { *v++ })
+		}
+	})
+}
+
+func BenchmarkMtx_Contended(b *testing.B)     { benchmarkContended(b, NewMtxPtr(0)) }
+func BenchmarkSpinMtx_Contended(b *testing.B) { benchmarkContended(b, NewSpinMtxPtr(0)) }
+
+func TestGoString(t *testing.T) {
+	assert.Equal(t, "mtx.Mtx[int]{42}", fmt.Sprintf("%#v", NewMtx(42)))
+	assert.Equal(t, `mtx.Mtx[string]{"a"}`, fmt.Sprintf("%#v", NewRWMtxPtr("a")))
+	assert.Equal(t, `mtx.Map[string,int]{map[string]int{"a":1, "b":2}}`, fmt.Sprintf("%#v", NewMap(map[string]int{"b": 2, "a": 1})))
+	assert.Equal(t, "mtx.Slice[int]{[]int{1, 2}}", fmt.Sprintf("%#v", NewSlice([]int{1, 2})))
+	assert.Equal(t, "mtx.Number[uint8]{0x7}", fmt.Sprintf("%#v", NewNumber(uint8(7))))
+	assert.Equal(t, "mtx.Mtx[int]{0}", fmt.Sprintf("%#v", Mtx[int]{}))
+	assert.Equal(t, "mtx.Map[string,int]{map[string]int(nil)}", fmt.Sprintf("%#v", Map[string, int]{}))
+	assert.Equal(t, "mtx.Slice[int]{[]int(nil)}", fmt.Sprintf("%#v", Slice[int]{}))
+	assert.Equal(t, "mtx.Number[int]{0}", fmt.Sprintf("%#v", Number[int]{}))
+}
+
+func TestNamedMtx(t *testing.T) {
+	m := NewNamedMtx("counter", 42)
+	assert.Equal(t, "counter", m.Name())
+	assert.Equal(t, "counter=42", m.String())
+	assert.Equal(t, `mtx.Mtx[int]{Name:"counter", Value:42}`, fmt.Sprintf("%#v", m))
+	assert.Equal(t, "counter", m.Clone().Name())
+	assert.Equal(t, "counter", m.ToRWMtx().Name())
+	assert.Equal(t, "", NewMtx(1).Name())
+	assert.Equal(t, "1", NewMtx(1).String())
+	assert.Equal(t, "hits=3", NewNumberNamed("hits", 3).String())
+	assert.Equal(t, `mtx.Slice[int]{Name:"s", Value:[]int{1}}`, fmt.Sprintf("%#v", NewSliceNamed("s", []int{1})))
+	assert.Equal(t, "m=map[a:1]", NewMapNamed("m", map[string]int{"a": 1}).String())
+}
+
+func TestMapSortedKeys(t *testing.T) {
+	m := NewRWMapPtr(map[string]int{"c": 1, "a": 2, "b": 3})
+	for range 10 {
+		assert.Equal(t, []string{"a", "b", "c"}, MapSortedKeys(m))
+		assert.Equal(t, []string{"c", "b", "a"}, m.KeysFunc(func(a, b string) bool { return a > b }))
+	}
+	assert.Equal(t, []int{}, MapSortedKeys(NewMapPtr(map[int]int{})))
+}
+
+func TestMap_Entries(t *testing.T) {
+	m := NewRWMapPtr(map[string]int{"a": 3, "b": 1, "c": 2})
+	entries := m.Entries()
+	assert.ElementsMatch(t, []Pair[string, int]{{"a", 3}, {"b", 1}, {"c", 2}}, entries)
+	byValue := m.SortedEntriesFunc(func(a, b Pair[string, int]) bool { return a.Value < b.Value })
+	assert.Equal(t, []Pair[string, int]{{"b", 1}, {"c", 2}, {"a", 3}}, byValue)
+	assert.Equal(t, []Pair[string, int]{}, NewMapPtr(map[string]int{}).Entries())
+}
+
+func TestMapKeysWhere(t *testing.T) {
+	m := NewRWMapPtr(map[string]int{"a": 1, "b": 20, "c": 30})
+	keys := MapKeysWhere(m, func(k string, v int) bool { return v > 10 })
+	slices.Sort(keys)
+	assert.Equal(t, []string{"b", "c"}, keys)
+	assert.Equal(t, []string{}, MapKeysWhere(m, func(k string, v int) bool { return false }))
+}
+
+func TestEqual(t *testing.T) {
+	a := NewMtxPtr(1)
+	b := NewRWMtxPtr(1)
+	c := NewMtxPtr(2)
+	assert.True(t, Equal(a, b))
+	assert.True(t, Equal(b, a))
+	assert.False(t, Equal(a, c))
+	assert.True(t, Equal(a, a))
+}
+
+func TestEqualFunc(t *testing.T) {
+	a := NewMtxPtr([]int{1, 2})
+	b := NewMtxPtr([]int{1, 2})
+	c := NewRWMtxPtr([]int{1, 3})
+	assert.True(t, EqualFunc(a, b, slices.Equal))
+	assert.False(t, EqualFunc(a, c, slices.Equal))
+	assert.True(t, EqualFunc(c, c, slices.Equal))
+}
+
+func TestMap_SetWAL(t *testing.T) {
+	m := NewRWMap[string, int](nil)
+	logs := make([]string, 0)
+	m.SetWAL(func(op string, k string, v int) {
+		logs = append(logs, fmt.Sprintf("%s %s %d", op, k, v))
+	})
+	m.Insert("a", 1)
+	m.Insert("b", 2)
+	m.Delete("a")
+	m.Delete("a")
+	_, _ = m.Remove("b")
+	_, _ = m.Remove("b")
+	m.Update("c", func(old int, existed bool) int { return old + 3 })
+	m.Merge(map[string]int{"d": 4})
+	m.Clear()
+	assert.Equal(t, []string{
+		"insert a 1",
+		"insert b 2",
+		"delete a 1",
+		"delete b 2",
+		"insert c 3",
+		"insert d 4",
+		"clear  0",
+	}, logs)
+}
+
+func TestMap_SetWAL_WholeMapWrites(t *testing.T) {
+	m := NewMapPtr[string, int](nil)
+	c := *m // copied before SetWAL, shares the hook
+	logs := make([]string, 0)
+	m.SetWAL(func(op string, k string, v int) {
+		logs = append(logs, fmt.Sprintf("%s %s %d", op, k, v))
+	})
+	c.Insert("a", 1)
+	m.Store(map[string]int{"b": 2})
+	_ = m.Swap(map[string]int{"c": 3})
+	_ = c.SwapFunc(func(old map[string]int) map[string]int { return map[string]int{"d": old["c"] + 1} })
+	m.Transaction(func(raw map[string]int) { delete(raw, "d") })
+	m.With(func(mm *map[string]int) { (*mm)["e"] = 5 }) // raw writes are not reported
+	assert.Equal(t, []string{
+		"insert a 1",
+		"clear  0", "insert b 2",
+		"clear  0", "insert c 3",
+		"clear  0", "insert d 4",
+		"clear  0",
+	}, logs)
+
+	l := &Map[string, int]{Locker: NewMtxPtr(map[string]int{"x": 1})}
+	l.SetWAL(func(op string, k string, v int) { logs = append(logs, op+" "+k) })
+	l.Insert("y", 2)
+	assert.Equal(t, "insert y", logs[len(logs)-1])
+	assert.Equal(t, 2, l.ApproxLen())
+}
+
+func TestProject(t *testing.T) {
+	type User struct {
+		Name string
+		Age  int
+	}
+	u := NewRWMtxPtr(User{Name: "alice", Age: 30})
+	assert.Equal(t, "alice", Project(u, func(u User) string { return u.Name }))
+	s := NewSlicePtr([]int{1, 2, 3})
+	assert.Equal(t, 3, Project(s, func(v []int) int { return len(v) }))
+}
+
+func TestRWith2(t *testing.T) {
+	a, b := NewMtxPtr(1), NewMtxPtr("b")
+	RWith2(a, b, func(x int, y string) {
+		assert.Equal(t, 1, x)
+		assert.Equal(t, "b", y)
+		assert.False(t, a.Locker.(*mtx[int]).m.TryLock())
+		assert.False(t, b.Locker.(*mtx[string]).m.TryLock())
+	})
+	RWith2(a, a, func(x, y int) { assert.Equal(t, x, y) }) // same locker is locked once
+
+	// opposite argument orders must not deadlock
+	c := NewMtxPtr(2)
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				RWith2(a, c, func(int, int) {})
+			} else {
+				RWith2(c, a, func(int, int) {})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWith2(t *testing.T) {
+	a, b := NewNumberPtr(1000), NewRWNumberPtr(1000)
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				With2(a, b, func(x, y *int) { *x -= 3; *y += 3 })
+			} else {
+				With2(b, a, func(y, x *int) { *y -= 5; *x += 5 })
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			RWith2(b, a, func(y, x int) { assert.Equal(t, 2000, x+y) })
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2000, a.Load()+b.Load())
+	assert.Equal(t, 1000+50*5-50*3, a.Load())
+
+	With2(a, a, func(x, y *int) { assert.Same(t, x, y) })
+}
+
+func TestWith3(t *testing.T) {
+	a, b, c := NewMtxPtr(1), NewRWMtxPtr("b"), NewSlicePtr([]int{})
+	With3(a, b, c, func(x *int, y *string, z *[]int) {
+		*z = append(*z, *x)
+		*x, *y = 2, "c"
+	})
+	assert.Equal(t, 2, a.Load())
+	assert.Equal(t, "c", b.Load())
+	assert.Equal(t, []int{1}, c.Load())
+}
+
+func TestLockAll(t *testing.T) {
+	a := NewMapPtr[string, int](nil)
+	b := NewRWMapPtr[string, int](nil)
+	var wg sync.WaitGroup
+	transfer := func(from, to *Map[string, int]) {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			unlock := LockAll(from, to)
+			(*to.GetPointer())["n"]++
+			(*from.GetPointer())["n"]--
+			unlock()
+		}
+	}
+	wg.Add(2)
+	go transfer(a, b)
+	go transfer(b, a)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+	assert.Equal(t, 0, first(a.Get("n")))
+	assert.Equal(t, 0, first(b.Get("n")))
+}
+
+func TestLockAll_Duplicates(t *testing.T) {
+	var mu sync.Mutex
+	m := NewMtxPtr(0)
+	unlock := LockAll(&mu, m, &mu, m)
+	assert.False(t, mu.TryLock())
+	unlock()
+	assert.True(t, mu.TryLock())
+	mu.Unlock()
+	assert.Panics(t, func() { LockAll(NewMtx(0)) })
+}
+
+func TestLockAll_CopiedWrappers(t *testing.T) {
+	a := NewMtx(1)
+	b := a // same backend
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		unlock := LockAll(&a, &b)
+		*b.GetPointer() = 2
+		unlock()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+	assert.Equal(t, 2, a.Load())
+}
+
+func TestLockAll_MixedWithWith2(t *testing.T) {
+	a := NewMtxPtr(0)
+	b := NewRWMtxPtr(0)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			// copies of the wrappers, ordered like With2 does
+			ca, cb := *a, *b
+			unlock := LockAll(&cb, &ca)
+			*ca.GetPointer()++
+			*cb.GetPointer()--
+			unlock()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			With2[int, int](a, b, func(x, y *int) {
+				*x--
+				*y++
+			})
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+	assert.Equal(t, 0, a.Load())
+	assert.Equal(t, 0, b.Load())
+}
+
+func TestMtx_Clone(t *testing.T) {
+	m := NewMtx("old")
+	c := m.Clone()
+	c.Store("new")
+	assert.Equal(t, "old", m.Load())
+	assert.Equal(t, "new", c.Load())
+	m.Lock()
+	assert.Equal(t, "new", c.Load()) // the clone does not share the original lock
+	m.Unlock()
+
+	rw := NewRWMtxPtr(1)
+	rwClone := rw.Clone()
+	assert.IsType(t, &rwMtx[int]{}, rwClone.Locker)
+	rwClone.Store(2)
+	assert.Equal(t, 1, rw.Load())
+
+	v := NewVersionedMtxPtr(1)
+	v.Store(2)
+	vClone := v.Clone()
+	assert.IsType(t, &versionedMtx[int]{}, vClone.Locker)
+	assert.Equal(t, uint64(0), vClone.Version())
+	assert.Equal(t, 2, vClone.Load())
+	assert.IsType(t, &dirtyMtx[int]{}, toPtr(NewDirtyMtx(1)).Clone().Locker)
+	assert.IsType(t, &debugMtx[int]{}, toPtr(NewMtxDebug(1, time.Second, func(time.Duration) {})).Clone().Locker)
+	assert.IsType(t, &mtx[int]{}, toPtr(NewLazyMtx(func() int { return 1 })).Clone().Locker)
+}
+
+func TestNumber_Clone(t *testing.T) {
+	n := NewRWNumber(1)
+	c := n.Clone()
+	c.Add(1)
+	assert.Equal(t, 1, n.Load())
+	assert.Equal(t, 2, c.Load())
+	assert.IsType(t, &rwMtx[int]{}, c.Locker)
+
+	changes := 0
+	o := NewObservableNumber(1, func(old, new int) { changes++ })
+	oc := o.Clone()
+	oc.Add(1)
+	assert.Equal(t, 1, changes)
+}
+
+func TestNumberClamp(t *testing.T) {
+	n := NewNumberPtr(-5)
+	NumberClamp(n, 0, 100)
+	assert.Equal(t, 0, n.Load())
+	n.Store(50)
+	NumberClamp(n, 0, 100)
+	assert.Equal(t, 50, n.Load())
+	n.Store(150)
+	NumberClamp(n, 0, 100)
+	assert.Equal(t, 100, n.Load())
+
+	f := NewRWNumberPtr(1.5)
+	NumberSetMin(f, 2.5)
+	assert.Equal(t, 1.5, f.Load())
+	NumberSetMin(f, 0.5)
+	assert.Equal(t, 0.5, f.Load())
+	NumberSetMax(f, 0.1)
+	assert.Equal(t, 0.5, f.Load())
+	NumberSetMax(f, 3.0)
+	assert.Equal(t, 3.0, f.Load())
+}
+
+func TestNumberStoreIfGreater(t *testing.T) {
+	n := NewNumberPtr(10)
+	assert.False(t, NumberStoreIfGreater(n, 5))
+	assert.False(t, NumberStoreIfGreater(n, 10))
+	assert.True(t, NumberStoreIfGreater(n, 11))
+	assert.Equal(t, 11, n.Load())
+	assert.False(t, NumberStoreIfLess(n, 11))
+	assert.True(t, NumberStoreIfLess(n, 3))
+	assert.Equal(t, 3, n.Load())
+}
+
+func TestNumberStoreIfGreater_Concurrent(t *testing.T) {
+	maxN, minN := NewRWNumberPtr(0), NewRWNumberPtr(math.MaxInt)
+	values := make([]int, 1000)
+	for i := range values {
+		values[i] = rand.IntN(1_000_000) + 1
+	}
+	var wg sync.WaitGroup
+	for _, v := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			NumberStoreIfGreater(maxN, v)
+			NumberStoreIfLess(minN, v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, slices.Max(values), maxN.Load())
+	assert.Equal(t, slices.Min(values), minN.Load())
+}
+
+func TestMtx_ToRWMtx(t *testing.T) {
+	m := NewMtx(1)
+	rw := m.ToRWMtx()
+	assert.IsType(t, &rwMtx[int]{}, rw.Locker)
+	assert.Equal(t, 1, rw.Load())
+	m.Lock()
+	rw.Store(2) // locks independently
+	m.Unlock()
+	assert.Equal(t, 1, m.Load())
+	assert.Equal(t, 2, rw.Load())
+}
+
+func TestMtx_ToMtx(t *testing.T) {
+	rw := NewRWMtx(1)
+	m := rw.ToMtx()
+	assert.IsType(t, &mtx[int]{}, m.Locker)
+	assert.Equal(t, 1, m.Load())
+	rw.Lock()
+	m.Store(2) // locks independently
+	rw.Unlock()
+	assert.Equal(t, 1, rw.Load())
+	assert.Equal(t, 2, m.Load())
+}
+
+func TestMtx_WaitFor(t *testing.T) {
+	for _, m := range []*Mtx[int]{NewMtxPtr(0), NewRWMtxPtr(0), NewCOWMtxPtr(0), toPtr(NewLazyMtx(func() int { return 5 }))} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			m.WaitFor(func(v int) bool { return v >= 10 })
+		}()
+		for range 10 {
+			m.With(func(v *int) { *v++ })
+		}
+		<-done
+		assert.GreaterOrEqual(t, m.Load(), 10)
+		m.WaitFor(func(v int) bool { return v >= 10 }) // already true, returns immediately
+	}
+}
+
+func TestMtx_Cond(t *testing.T) {
+	m := NewRWMtxPtr(0)
+	c := m.Cond()
+	assert.Same(t, c, m.Cond())
+
+	ready := make(chan struct{})
+	done := make(chan int)
+	go func() {
+		m.Lock()
+		defer m.Unlock()
+		close(ready)
+		for *m.GetPointer() == 0 {
+			c.Wait()
+		}
+		done <- *m.GetPointer()
+	}()
+	<-ready
+	m.Store(1) // broadcasts automatically
+	assert.Equal(t, 1, <-done)
+
+	// manual mutation, the caller broadcasts
+	go func() {
+		m.Lock()
+		defer m.Unlock()
+		for *m.GetPointer() < 2 {
+			c.Wait()
+		}
+		done <- *m.GetPointer()
+	}()
+	m.Lock()
+	*m.GetPointer() = 2
+	c.Broadcast()
+	m.Unlock()
+	assert.Equal(t, 2, <-done)
+}
+
+func TestMtx_Watch(t *testing.T) {
+	m := NewRWMtx(0)
+	ch, unwatch := m.Watch()
+	m.Store(1)
+	assert.Equal(t, 1, <-ch)
+	assert.Equal(t, 1, m.Swap(2))
+	assert.Equal(t, 2, <-ch)
+	m.With(func(v *int) { *v = 3 })
+	assert.Equal(t, 3, <-ch)
+	_ = m.Load()
+	select {
+	case <-ch:
+		t.Fatal("reads should not notify")
+	default:
+	}
+	assert.Error(t, m.WithE(func(v *int) error { return errors.New("failed") }))
+	assert.Error(t, m.WithRecover(func(v *int) { panic("failed") }))
+	select {
+	case <-ch:
+		t.Fatal("failed writes should not notify")
+	default:
+	}
+	m.Store(4)
+	m.Store(5) // slow watcher only gets the most recent value
+	assert.Equal(t, 5, <-ch)
+	unwatch()
+	unwatch()
+	m.Store(6)
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestStoreIfChanged(t *testing.T) {
+	for _, m := range []*Mtx[int]{NewMtxPtr(0), NewRWMtxPtr(0), NewCOWMtxPtr(0)} {
+		ch, unwatch := m.Watch()
+		assert.True(t, StoreIfChanged(m, 1))
+		assert.Equal(t, 1, <-ch)
+		assert.False(t, StoreIfChanged(m, 1))
+		select {
+		case <-ch:
+			t.Fatal("unchanged value should not notify")
+		default:
+		}
+		assert.True(t, StoreIfChanged(m, 2))
+		assert.Equal(t, 2, <-ch)
+		assert.Equal(t, 2, *m.LoadShared())
+		unwatch()
+	}
+}
+
+func TestNewDirtyMtx(t *testing.T) {
+	m := NewDirtyMtx(1)
+	assert.False(t, m.IsDirty())
+	_ = m.Load()
+	m.RWith(func(v int) {})
+	assert.False(t, m.IsDirty())
+	m.Store(2)
+	assert.True(t, m.IsDirty())
+	m.ClearDirty()
+	assert.False(t, m.IsDirty())
+	m.With(func(v *int) { *v++ })
+	assert.True(t, m.IsDirty())
+	m.ClearDirty()
+	assert.False(t, StoreIfChanged(&m, 3))
+	assert.False(t, m.IsDirty())
+	assert.True(t, m.WithTimeout(time.Second, func(v *int) {}))
+	assert.True(t, m.IsDirty())
+	assert.Equal(t, 3, m.Load())
+
+	plain := NewMtx(1)
+	plain.Store(2)
+	assert.False(t, plain.IsDirty())
+	plain.ClearDirty()
+}
+
+func TestNewVersionedMtx(t *testing.T) {
+	m := NewVersionedMtx("a")
+	assert.Equal(t, uint64(0), m.Version())
+	_ = m.Load()
+	assert.Equal(t, uint64(0), m.Version())
+	m.Store("b")
+	m.With(func(v *string) { *v += "!" })
+	assert.Equal(t, uint64(2), m.Version())
+
+	ver := m.Version()
+	m.Store("concurrent write")
+	assert.False(t, m.CompareVersionAndSwap(ver, "stale"))
+	assert.Equal(t, "concurrent write", m.Load())
+	ver = m.Version()
+	assert.True(t, m.CompareVersionAndSwap(ver, "fresh"))
+	assert.Equal(t, "fresh", m.Load())
+	assert.Equal(t, ver+1, m.Version())
+
+	// optimistic retry loop
+	n := NewVersionedMtxPtr(0)
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				ver, cur := n.Version(), n.Load()
+				if n.CompareVersionAndSwap(ver, cur+1) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, n.Load())
+
+	plain := NewMtx(1)
+	assert.False(t, plain.CompareVersionAndSwap(0, 2))
+	assert.Equal(t, uint64(0), plain.Version())
+}
+
+func TestMtx_UnsupportedBackend(t *testing.T) {
+	m := &Mtx[int]{Locker: NewMtxPtr(1)}
+	msg := "mtx: unsupported backend *mtx.Mtx[int]"
+	assert.PanicsWithValue(t, msg, func() { m.Watch() })
+	assert.PanicsWithValue(t, msg, func() { m.WaitFor(func(int) bool { return true }) })
+	assert.PanicsWithValue(t, msg, func() { m.Cond() })
+	assert.PanicsWithValue(t, msg, func() { StoreIfChanged(m, 2) })
+
+	ext := Mtx[int]{Locker: struct{ Locker[int] }{NewMtxPtr(1)}} // only implements Locker
+	assert.Panics(t, func() { ext.WithTimeout(time.Second, func(*int) {}) })
+	assert.Panics(t, func() { ext.RLocker() })
+	assert.NoError(t, ext.WithRecover(func(v *int) { *v = 2 }))
+	assert.Equal(t, 2, ext.Load())
+}
+
+func TestMtx_Watch_Multiple(t *testing.T) {
+	m := NewMtxPtr("a")
+	ch1, unwatch1 := m.Watch()
+	ch2, unwatch2 := m.Watch()
+	defer unwatch2()
+	m.Store("b")
+	assert.Equal(t, "b", <-ch1)
+	assert.Equal(t, "b", <-ch2)
+	unwatch1()
+	m.Store("c")
+	assert.Equal(t, "c", <-ch2)
+}
+
+func TestObservableNumber(t *testing.T) {
+	type change struct{ old, new int }
+	changes := make([]change, 0)
+	var n Number[int]
+	n = NewObservableNumber(10, func(old, new int) {
+		assert.Equal(t, new, n.Load()) // the lock is not held while the callback runs
+		changes = append(changes, change{old, new})
+	})
+	n.Add(5)
+	n.Sub(3)
+	n.Store(20)
+	assert.Equal(t, 20, n.Swap(1))
+	assert.Equal(t, []change{{10, 15}, {15, 12}, {12, 20}, {20, 1}}, changes)
+}
+
+func TestLazyMtx(t *testing.T) {
+	calls := NewNumberPtr(0)
+	m := NewLazyMtx(func() string {
+		calls.Add(1)
+		return "init"
+	})
+	assert.Equal(t, 0, calls.Load())
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "init", m.Load())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, calls.Load())
+	m.Store("new")
+	assert.Equal(t, "new", m.Load())
+	assert.Equal(t, 1, calls.Load())
+}
+
+func TestLazyMtx_With(t *testing.T) {
+	m := NewLazyMtx(func() []int { return []int{1} })
+	m.With(func(v *[]int) { *v = append(*v, 2) })
+	assert.Equal(t, []int{1, 2}, m.Load())
+	s := NewLazyMtx(func() int { return 1 })
+	s.Store(2) // the value is initialized before being overwritten
+	assert.Equal(t, 2, s.Load())
+}
+
+func TestRLocker(t *testing.T) {
+	readWith := func(l sync.Locker, clb func()) {
+		l.Lock()
+		defer l.Unlock()
+		clb()
+	}
+	rw := NewRWMtxPtr(1)
+	readWith(rw.RLocker(), func() {
+		assert.Equal(t, 1, *rw.GetPointer())
+		rw.RWith(func(v int) { assert.Equal(t, 1, v) }) // other readers are not blocked
+		assert.False(t, rw.Locker.(*rwMtx[int]).m.TryLock())
+	})
+	m := NewMtxPtr(2)
+	readWith(m.RLocker(), func() {
+		assert.Equal(t, 2, *m.GetPointer())
+		assert.False(t, m.Locker.(*mtx[int]).m.TryLock())
+	})
+	assert.Equal(t, 2, m.Load())
+	mm := NewRWMapPtr(map[string]int{"a": 1})
+	readWith(mm.RLocker(), func() { assert.Equal(t, 1, mm.Len()) })
+	sl := NewRWSlicePtr([]int{1})
+	readWith(sl.RLocker(), func() { assert.Equal(t, 1, sl.Len()) })
+	n := NewRWNumberPtr(3)
+	readWith(n.RLocker(), func() { n.RWith(func(v int) { assert.Equal(t, 3, v) }) })
+}
+
+func TestMap_TxE(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 10, "b": 0})
+	errInsufficient := errors.New("insufficient funds")
+	transfer := func(from, to string, amount int) func(map[string]int) error {
+		return func(mm map[string]int) error {
+			mm[to] += amount
+			if mm[from] < amount {
+				return errInsufficient
+			}
+			mm[from] -= amount
+			return nil
+		}
+	}
+	assert.NoError(t, m.TxE(transfer("a", "b", 4)))
+	assert.Equal(t, map[string]int{"a": 6, "b": 4}, m.Load())
+	assert.ErrorIs(t, m.TxE(transfer("a", "b", 7)), errInsufficient)
+	assert.Equal(t, map[string]int{"a": 6, "b": 4}, m.Load())
+
+	logged := make(map[string]int)
+	m.SetWAL(func(op string, k string, v int) {
+		if op == WALClear {
+			clear(logged)
+			return
+		}
+		logged[k] = v
+	})
+	assert.Error(t, m.TxE(transfer("a", "b", 7)))
+	assert.Empty(t, logged) // rollbacks are not reported
+	assert.NoError(t, m.TxE(transfer("b", "a", 1)))
+	assert.Equal(t, m.Load(), logged)
+}
+
+func TestMap_Rebuild_NilMap(t *testing.T) {
+	m := &Map[string, int]{Locker: NewMtxPtr[map[string]int](nil)}
+	m.Rebuild(func(old map[string]int) map[string]int {
+		old["a"] = 1
+		return old
+	})
+	assert.Equal(t, map[string]int{"a": 1}, m.Load())
+}
+
+func TestMap_TxE_NilMap(t *testing.T) {
+	m := &Map[string, int]{Locker: NewMtxPtr[map[string]int](nil)}
+	assert.NoError(t, m.TxE(func(mm map[string]int) error {
+		mm["a"] = 1
+		return nil
+	}))
+	assert.Equal(t, map[string]int{"a": 1}, m.Load())
+}
+
+func TestMap_Pop(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	popped := make(map[string]int)
+	for {
+		k, v, ok := m.Pop()
+		if !ok {
+			break
+		}
+		_, seen := popped[k]
+		assert.False(t, seen)
+		popped[k] = v
+	}
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, popped)
+	assert.True(t, m.IsEmpty())
+	k, v, ok := m.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, "", k)
+	assert.Equal(t, 0, v)
+}
+
+func TestSlice_Retain(t *testing.T) {
+	s := NewRWSlice([]int{1, 2, 3, 4, 5, 6})
+	s.Retain(func(el int) bool { return el%2 == 0 })
+	assert.Equal(t, []int{2, 4, 6}, s.Load())
+	s.Retain(func(el int) bool { return true })
+	assert.Equal(t, []int{2, 4, 6}, s.Load())
+	s.Retain(func(el int) bool { return false })
+	assert.Equal(t, []int{}, s.Load())
+}
+
+func TestMap_DeleteFunc(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2, "c": 3, "d": 4})
+	isEven := func(k string, v int) bool { return v%2 == 0 }
+	assert.Equal(t, 2, m.DeleteFunc(isEven))
+	assert.Equal(t, map[string]int{"a": 1, "c": 3}, m.Load())
+	assert.Equal(t, 0, m.DeleteFunc(isEven))
+}
+
+func TestMapCompareAndDelete(t *testing.T) {
+	m := NewRWMapPtr(map[string]int{"a": 1, "b": 2})
+	assert.False(t, MapCompareAndDelete(m, "a", 2))
+	assert.True(t, m.ContainsKey("a"))
+	assert.True(t, MapCompareAndDelete(m, "a", 1))
+	assert.False(t, m.ContainsKey("a"))
+	assert.False(t, MapCompareAndDelete(m, "c", 0))
+	assert.Equal(t, map[string]int{"b": 2}, m.Load())
+}
+
+func TestMapInvert(t *testing.T) {
+	m := NewRWMapPtr(map[string]int{"a": 1, "b": 2})
+	assert.Equal(t, map[int]string{1: "a", 2: "b"}, MapInvert(m))
+	m.Insert("c", 2)
+	inv := MapInvert(m)
+	assert.Equal(t, 2, len(inv))
+	assert.Equal(t, "a", inv[1])
+	assert.Contains(t, []string{"b", "c"}, inv[2])
+}
+
+func TestMapEqual(t *testing.T) {
+	a := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	b := NewRWMapPtr(map[string]int{"b": 2, "a": 1})
+	assert.True(t, MapEqual(a, b))
+	assert.True(t, MapEqual(a, a))
+	b.Insert("c", 3)
+	assert.False(t, MapEqual(a, b))
+	b.Delete("c")
+	b.Insert("a", 5)
+	assert.False(t, MapEqual(a, b))
+
+	s1 := NewMapPtr(map[string][]int{"a": {1, 2}})
+	s2 := NewMapPtr(map[string][]int{"a": {1, 2}})
+	assert.True(t, MapEqualFunc(s1, s2, slices.Equal[[]int]))
+	s2.Insert("a", []int{1})
+	assert.False(t, MapEqualFunc(s1, s2, slices.Equal[[]int]))
+}
+
+func TestMap_LoadAndDelete(t *testing.T) {
+	m := NewRWMap(map[string]int{"a": 1})
+	v, loaded := m.LoadAndDelete("a")
+	assert.True(t, loaded)
+	assert.Equal(t, 1, v)
+	v, loaded = m.LoadAndDelete("a")
+	assert.False(t, loaded)
+	assert.Equal(t, 0, v)
+	assert.True(t, m.IsEmpty())
+}
+
+func TestMap_LoadOrStore(t *testing.T) {
+	m := NewMap[string, int](nil)
+	actual, loaded := m.LoadOrStore("a", 1)
+	assert.False(t, loaded)
+	assert.Equal(t, 1, actual)
+	actual, loaded = m.LoadOrStore("a", 2)
+	assert.True(t, loaded)
+	assert.Equal(t, 1, actual)
+	assert.Equal(t, map[string]int{"a": 1}, m.Load())
+}
+
+func TestWithTimeout(t *testing.T) {
+	for _, m := range []*Mtx[int]{NewMtxPtr(0), NewRWMtxPtr(0), NewSpinMtxPtr(0), NewCOWMtxPtr(0)} {
+		assert.True(t, m.WithTimeout(time.Second, func(v *int) { *v = 1 }))
+		assert.Equal(t, 1, m.Load())
+
+		locked := make(chan struct{})
+		release := make(chan struct{})
+		go func() {
+			m.With(func(v *int) {
+				close(locked)
+				<-release
+			})
+		}()
+		<-locked
+		called := false
+		assert.False(t, m.WithTimeout(10*time.Millisecond, func(v *int) { called = true }))
+		assert.False(t, called)
+		close(release)
+		assert.True(t, m.WithTimeout(time.Second, func(v *int) { *v = 2 }))
+		assert.Equal(t, 2, m.Load())
+		assert.Equal(t, 2, *m.LoadShared())
+	}
+	sl := NewSlicePtr([]int{})
+	assert.True(t, sl.WithTimeout(time.Second, func(v *[]int) { *v = append(*v, 1) }))
+	assert.Equal(t, []int{1}, sl.Load())
+	n := NewNumberPtr(0)
+	assert.True(t, n.WithTimeout(time.Second, func(v *int) { *v = 1 }))
+	assert.Equal(t, 1, n.Load())
+	mm := NewMapPtr[string, int](nil)
+	assert.True(t, mm.WithTimeout(time.Second, func(v *map[string]int) { (*v)["a"] = 1 }))
+	assert.Equal(t, 1, mm.ApproxLen())
+}
+
+func TestWithRecover(t *testing.T) {
+	errBoom := errors.New("boom")
+	for _, m := range []*Mtx[int]{NewMtxPtr(0), NewRWMtxPtr(0), NewCOWMtxPtr(0), toPtr(NewLazyMtx(func() int { return 0 }))} {
+		err := m.WithRecover(func(v *int) { *v = 1; panic(errBoom) })
+		assert.ErrorIs(t, err, errBoom)
+		err = m.WithRecover(func(v *int) { *v = 2; panic("oops") })
+		assert.ErrorContains(t, err, "oops")
+		assert.NoError(t, m.WithRecover(func(v *int) { *v++ }))
+		assert.True(t, m.WithTimeout(time.Second, func(v *int) {})) // lock was released
+		assert.Equal(t, 3, m.Load())
+		assert.Equal(t, 3, *m.LoadShared())
+	}
+	assert.Error(t, NewSlicePtr([]int{}).WithRecover(func(v *[]int) { panic("oops") }))
+	assert.Error(t, NewNumberPtr(0).WithRecover(func(v *int) { panic("oops") }))
+	assert.Error(t, NewMapPtr[string, int](nil).WithRecover(func(v *map[string]int) { panic("oops") }))
+}
+
+func TestSwapFunc(t *testing.T) {
+	for _, m := range []*Mtx[int]{NewMtxPtr(1), NewRWMtxPtr(1), NewCOWMtxPtr(1), NewVersionedMtxPtr(1), toPtr(NewLazyMtx(func() int { return 1 }))} {
+		assert.Equal(t, 1, m.SwapFunc(func(old int) int { return old + 1 }))
+		assert.Equal(t, 2, m.Load())
+		assert.Equal(t, 2, *m.LoadShared())
+	}
+	n := NewObservableNumber(1, func(old, new int) { assert.Equal(t, old+10, new) })
+	assert.Equal(t, 1, n.SwapFunc(func(old int) int { return old + 10 }))
+	mm := NewMap(map[string]int{"a": 1})
+	old := mm.SwapFunc(func(old map[string]int) map[string]int { return map[string]int{"b": 2, "c": 3} })
+	assert.Equal(t, map[string]int{"a": 1}, old)
+	assert.Equal(t, 2, mm.ApproxLen())
+	s := NewSlicePtr([]int{1, 2})
+	assert.Equal(t, []int{1, 2}, s.SwapFunc(func(old []int) []int { return append(slices.Clone(old), 3) }))
+	assert.Equal(t, []int{1, 2, 3}, s.Load())
+}
+
+func TestMtxDebug(t *testing.T) {
+	reports := make([]time.Duration, 0)
+	m := NewMtxDebug(0, 20*time.Millisecond, func(held time.Duration) {
+		reports = append(reports, held)
+	})
+	m.Store(1)
+	m.With(func(v *int) { *v++ })
+	assert.Equal(t, 0, len(reports))
+	m.With(func(v *int) { time.Sleep(30 * time.Millisecond) })
+	assert.Equal(t, 1, len(reports))
+	assert.GreaterOrEqual(t, reports[0], 30*time.Millisecond)
+	m.Lock()
+	time.Sleep(30 * time.Millisecond)
+	m.Unlock()
+	assert.True(t, m.WithTimeout(time.Second, func(v *int) { time.Sleep(30 * time.Millisecond) }))
+	assert.Equal(t, 3, len(reports))
+	assert.Equal(t, 2, m.Load())
+}
@@ -0,0 +1,54 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package mtxtest provides helpers to test code using mtx types.
+package mtxtest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alaingilbert/mtx"
+)
+
+// StressTest runs "op" concurrently on m from "goroutines" goroutines, "iterations" times each,
+// and waits for all of them to finish. It is meant to be used with the race detector (go test -race).
+// A panic inside "op" is reported as a test error instead of crashing the test binary.
+func StressTest[T any](tb testing.TB, m mtx.Locker[T], goroutines, iterations int, op func(mtx.Locker[T])) {
+	tb.Helper()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					tb.Errorf("goroutine %d panicked: %v", g, r)
+				}
+			}()
+			for i := 0; i < iterations; i++ {
+				op(m)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
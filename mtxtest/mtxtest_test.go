@@ -0,0 +1,61 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtxtest
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/alaingilbert/mtx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStressTest(t *testing.T) {
+	n := mtx.NewNumberPtr(0)
+	StressTest[int](t, n, 10, 100, func(m mtx.Locker[int]) {
+		m.With(func(v *int) { *v++ })
+	})
+	assert.Equal(t, 1000, n.Load())
+}
+
+// recordingTB records reported errors instead of failing the test
+type recordingTB struct {
+	testing.TB
+	errors mtx.Slice[string]
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors.Append(fmt.Sprintf(format, args...))
+}
+
+func TestStressTest_Panic(t *testing.T) {
+	m := mtx.NewMtxPtr(0)
+	tb := &recordingTB{errors: mtx.NewSlice[string](nil)}
+	StressTest[int](tb, m, 2, 1, func(m mtx.Locker[int]) { panic("boom") })
+	errs := tb.errors.Load()
+	slices.Sort(errs)
+	assert.Equal(t, []string{"goroutine 0 panicked: boom", "goroutine 1 panicked: boom"}, errs)
+}
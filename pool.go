@@ -0,0 +1,60 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import "sync"
+
+// MtxPool a sync.Pool of reusable mutex protected values
+type MtxPool[T any] struct {
+	p     sync.Pool
+	newFn func() T
+}
+
+// NewMtxPool returns a new MtxPool, newFn is used to create a value when the pool is empty,
+// and to reset values that do not have a Reset method
+func NewMtxPool[T any](newFn func() T) *MtxPool[T] {
+	mp := &MtxPool[T]{newFn: newFn}
+	mp.p.New = func() any { return NewMtxPtr(newFn()) }
+	return mp
+}
+
+// Get returns a protected value from the pool, creating one with newFn if the pool is empty
+func (mp *MtxPool[T]) Get() *Mtx[T] { return mp.p.Get().(*Mtx[T]) }
+
+// Put resets m and returns it to the pool.
+// If the value has a Reset() method, through a pointer to it (eg: bytes.Buffer) or because it is itself
+// a pointer (eg: *bytes.Buffer), it is used to reset the value in place, so that buffers keep their allocated memory,
+// otherwise the value is replaced by a new one from newFn.
+// m must not be used after calling Put.
+func (mp *MtxPool[T]) Put(m *Mtx[T]) {
+	m.With(func(v *T) {
+		if r, ok := any(v).(interface{ Reset() }); ok {
+			r.Reset()
+		} else if r, ok := any(*v).(interface{ Reset() }); ok {
+			r.Reset()
+		} else {
+			*v = mp.newFn()
+		}
+	})
+	mp.p.Put(m)
+}
@@ -0,0 +1,76 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMtxPool(t *testing.T) {
+	p := NewMtxPool(func() *bytes.Buffer { return new(bytes.Buffer) })
+	// sync.Pool may drop items (randomly so under the race detector), retry until m is handed back
+	reused := false
+	for i := 0; i < 100 && !reused; i++ {
+		m := p.Get()
+		buf := m.Load()
+		m.With(func(b **bytes.Buffer) { (*b).WriteString("hello") })
+		p.Put(m)
+		m2 := p.Get()
+		if reused = m2 == m; reused {
+			assert.Same(t, buf, m2.Load()) // reset in place, keeping its memory
+			assert.Equal(t, 0, m2.Load().Len())
+		}
+		p.Put(m2)
+	}
+	assert.True(t, reused)
+}
+
+func TestMtxPool_ResetWithNewFn(t *testing.T) {
+	p := NewMtxPool(func() []int { return []int{0} })
+	m := p.Get()
+	m.Store([]int{1, 2, 3})
+	p.Put(m)
+	// either m reset by Put, or a new value if the pool dropped it
+	assert.Equal(t, []int{0}, p.Get().Load())
+}
+
+func TestMtxPool_ResetPointerReceiver(t *testing.T) {
+	newCalls := 0
+	p := NewMtxPool(func() bytes.Buffer { newCalls++; return bytes.Buffer{} })
+	m := p.Get()
+	m.With(func(b *bytes.Buffer) { b.WriteString("hello") })
+	p.Put(m)
+	assert.Equal(t, 1, newCalls) // reset in place through *bytes.Buffer, not replaced by newFn
+}
+
+func TestMtxPool_IndependentLocks(t *testing.T) {
+	p := NewMtxPool(func() int { return 0 })
+	m1, m2 := p.Get(), p.Get()
+	m1.Lock()
+	defer m1.Unlock()
+	assert.True(t, m2.WithTimeout(time.Second, func(v *int) { *v = 2 }))
+	assert.Equal(t, 2, m2.Load())
+}
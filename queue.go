@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"context"
+	"sync"
+)
+
+// Queue mutex protected FIFO queue, backed by a ring buffer.
+// Consumers can block until an element is available with DequeueWait.
+type Queue[T any] struct {
+	m    *mtx[ring[T]]
+	cond *sync.Cond
+}
+
+// NewQueue returns a new empty Queue with a sync.Mutex as backend
+func NewQueue[T any]() Queue[T] {
+	m := newMtxPtr(ring[T]{})
+	return Queue[T]{m: m, cond: sync.NewCond(m.m)}
+}
+
+// NewQueuePtr same as NewQueue, but as a pointer
+func NewQueuePtr[T any]() *Queue[T] { return toPtr(NewQueue[T]()) }
+
+// Enqueue adds el at the back of the queue, and wakes up one consumer waiting in DequeueWait
+func (q *Queue[T]) Enqueue(el T) {
+	q.m.With(func(r *ring[T]) { r.pushBack(el) })
+	q.cond.Signal()
+}
+
+// Dequeue removes and returns the element at the front of the queue, or false if the queue is empty
+func (q *Queue[T]) Dequeue() (out T, ok bool) {
+	q.m.With(func(r *ring[T]) { out, ok = r.popFront() })
+	return
+}
+
+// DequeueWait same as Dequeue, but blocks until an element is available.
+// Returns false if ctx is done before an element could be dequeued.
+func (q *Queue[T]) DequeueWait(ctx context.Context) (out T, ok bool) {
+	stop := context.AfterFunc(ctx, func() {
+		// Take the lock so the broadcast cannot happen between the ctx check and cond.Wait
+		q.m.Lock()
+		defer q.m.Unlock()
+		q.cond.Broadcast()
+	})
+	defer stop()
+	q.m.Lock()
+	defer q.m.Unlock()
+	for q.m.v.size == 0 {
+		if ctx.Err() != nil {
+			return
+		}
+		q.cond.Wait()
+	}
+	return q.m.v.popFront()
+}
+
+// Len returns the number of elements in the queue
+func (q *Queue[T]) Len() (out int) {
+	q.m.RWith(func(r ring[T]) { out = r.size })
+	return
+}
@@ -0,0 +1,75 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	q := NewQueue[int]()
+	_, ok := q.Dequeue()
+	assert.False(t, ok)
+	for i := range 20 {
+		q.Enqueue(i)
+	}
+	assert.Equal(t, 20, q.Len())
+	for i := range 20 {
+		assert.Equal(t, i, first(q.Dequeue()))
+	}
+	assert.Equal(t, 0, q.Len())
+	_, ok = q.Dequeue()
+	assert.False(t, ok)
+}
+
+func TestQueue_DequeueWait(t *testing.T) {
+	q := NewQueuePtr[string]()
+	got := make(chan string)
+	go func() {
+		v, _ := q.DequeueWait(context.Background())
+		got <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-got:
+		t.Fatal("consumer should be blocked")
+	default:
+	}
+	q.Enqueue("a")
+	assert.Equal(t, "a", <-got)
+}
+
+func TestQueue_DequeueWait_Cancel(t *testing.T) {
+	q := NewQueuePtr[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, ok := q.DequeueWait(ctx)
+	assert.False(t, ok)
+	q.Enqueue(1)
+	v, ok := q.DequeueWait(context.Background())
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
@@ -0,0 +1,46 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+// ReadOnly read-only view of a mutex protected value.
+// It exposes only the read methods, so it can be handed to code that must not mutate the value.
+// Changes made through the original are visible through the view.
+type ReadOnly[T any] struct{ l Locker[T] }
+
+// ReadOnly returns a read-only view of the protected value
+func (m *Mtx[T]) ReadOnly() ReadOnly[T] { return ReadOnly[T]{m.Locker} }
+
+// Load safely gets the wrapped value
+func (r ReadOnly[T]) Load() T { return r.l.Load() }
+
+// RWith provide a callback scope where the wrapped value can be safely used for Read only purposes
+func (r ReadOnly[T]) RWith(clb func(v T)) { r.l.RWith(clb) }
+
+// RWithE same as RWith, but the callback can return an error
+func (r ReadOnly[T]) RWithE(clb func(v T) error) error { return r.l.RWithE(clb) }
+
+// RLock exposes the underlying read lock
+func (r ReadOnly[T]) RLock() { r.l.RLock() }
+
+// RUnlock exposes the underlying read unlock
+func (r ReadOnly[T]) RUnlock() { r.l.RUnlock() }
@@ -0,0 +1,54 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	m := NewRWMtxPtr(1)
+	ro := m.ReadOnly()
+	var reader interface {
+		Load() int
+		RWith(func(int))
+		RWithE(func(int) error) error
+		RLock()
+		RUnlock()
+	} = ro
+	_, canWrite := reader.(interface{ Store(int) })
+	assert.False(t, canWrite)
+
+	assert.Equal(t, 1, ro.Load())
+	m.Store(2)
+	assert.Equal(t, 2, ro.Load())
+	ro.RWith(func(v int) { assert.Equal(t, 2, v) })
+	errTest := errors.New("test")
+	assert.ErrorIs(t, ro.RWithE(func(v int) error { return errTest }), errTest)
+	ro.RLock()
+	m.RLock() // RWMutex backend, readers share the lock
+	m.RUnlock()
+	ro.RUnlock()
+}
@@ -0,0 +1,92 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Snapshot point-in-time capture of a protected value, and some metadata about it
+type Snapshot[T any] struct {
+	Value     T         // copy of the value, maps and slices are cloned (shallow)
+	Time      time.Time // when the snapshot was taken
+	Version   uint64    // version of the value, see NewVersionedMtx
+	Versioned bool      // whether the value is versioned, Version is always 0 otherwise
+}
+
+// Snapshot captures the value, cloned if it is a map or a slice, along with its version, under the read lock
+func (m *Mtx[T]) Snapshot() Snapshot[T] { return m.SnapshotWithClock(realClock{}) }
+
+// SnapshotWithClock same as Snapshot, but uses "clock" to timestamp the snapshot
+func (m *Mtx[T]) SnapshotWithClock(clock Clock) Snapshot[T] {
+	return takeSnapshot(m.Locker, cloneValue[T], clock)
+}
+
+// Snapshot captures a clone of the map under the read lock
+func (m *Map[K, V]) Snapshot() Snapshot[map[K]V] { return m.SnapshotWithClock(realClock{}) }
+
+// SnapshotWithClock same as Snapshot, but uses "clock" to timestamp the snapshot
+func (m *Map[K, V]) SnapshotWithClock(clock Clock) Snapshot[map[K]V] {
+	return takeSnapshot(m.Locker, maps.Clone[map[K]V], clock)
+}
+
+// Snapshot captures a clone of the slice under the read lock
+func (s *Slice[T]) Snapshot() Snapshot[[]T] { return s.SnapshotWithClock(realClock{}) }
+
+// SnapshotWithClock same as Snapshot, but uses "clock" to timestamp the snapshot
+func (s *Slice[T]) SnapshotWithClock(clock Clock) Snapshot[[]T] {
+	return takeSnapshot(s.Locker, slices.Clone[[]T], clock)
+}
+
+// captures the value of l using clone, and its version if l is versioned, in a single locked section
+func takeSnapshot[T any](l Locker[T], clone func(T) T, clock Clock) (out Snapshot[T]) {
+	l.RWith(func(v T) {
+		out.Value = clone(v)
+		out.Time = clock.Now()
+		if vm, ok := l.(*versionedMtx[T]); ok {
+			out.Version, out.Versioned = vm.version, true // the lock is held, RLock is Lock for a sync.Mutex
+		}
+	})
+	return
+}
+
+// returns a shallow clone of v if it is a map or a slice, v otherwise
+func cloneValue[T any](v T) T {
+	rv := reflect.ValueOf(&v).Elem()
+	switch {
+	case rv.Kind() == reflect.Map && !rv.IsNil():
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for it := rv.MapRange(); it.Next(); {
+			c.SetMapIndex(it.Key(), it.Value())
+		}
+		rv.Set(c)
+	case rv.Kind() == reflect.Slice && !rv.IsNil():
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(c, rv)
+		rv.Set(c)
+	}
+	return v
+}
@@ -0,0 +1,75 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMtx_Snapshot(t *testing.T) {
+	m := NewVersionedMtxPtr([]int{1, 2})
+	m.With(func(v *[]int) { *v = append(*v, 3) })
+	before := time.Now()
+	snap := m.Snapshot()
+	assert.Equal(t, []int{1, 2, 3}, snap.Value)
+	assert.Equal(t, uint64(1), snap.Version)
+	assert.True(t, snap.Versioned)
+	assert.False(t, snap.Time.Before(before))
+	m.With(func(v *[]int) { (*v)[0] = 9 })
+	assert.Equal(t, []int{1, 2, 3}, snap.Value)
+	assert.Equal(t, uint64(2), m.Snapshot().Version)
+
+	plain := NewMtxPtr(map[string]int{"a": 1}).Snapshot()
+	assert.False(t, plain.Versioned)
+	assert.Equal(t, map[string]int{"a": 1}, plain.Value)
+	assert.Equal(t, 1, NewMtxPtr(1).Snapshot().Value)
+}
+
+func TestMap_Snapshot(t *testing.T) {
+	m := NewRWMapPtr(map[string]int{"a": 1})
+	snap := m.Snapshot()
+	m.Insert("a", 2)
+	m.Insert("b", 3)
+	assert.Equal(t, map[string]int{"a": 1}, snap.Value)
+	assert.False(t, snap.Versioned)
+}
+
+func TestSlice_Snapshot(t *testing.T) {
+	s := NewSlicePtr([]int{1, 2})
+	snap := s.Snapshot()
+	s.Set(0, 9)
+	s.Append(3)
+	assert.Equal(t, []int{1, 2}, snap.Value)
+}
+
+func TestSnapshotWithClock(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := ClockFunc(func() time.Time { return now })
+	assert.Equal(t, now, NewMtxPtr(1).SnapshotWithClock(clock).Time)
+	assert.Equal(t, now, NewMapPtr(map[string]int{"a": 1}).SnapshotWithClock(clock).Time)
+	snap := NewSlicePtr([]int{1}).SnapshotWithClock(clock)
+	assert.Equal(t, now, snap.Time)
+	assert.Equal(t, []int{1}, snap.Value)
+}
@@ -0,0 +1,71 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import "sync"
+
+// SyncMap is a typed drop-in replacement for sync.Map, backed by a Map with a sync.RWMutex.
+// The zero SyncMap is empty and ready for use. A SyncMap must not be copied after first use.
+type SyncMap[K comparable, V any] struct {
+	once sync.Once
+	m    Map[K, V]
+}
+
+// NewSyncMap returns a new empty SyncMap
+func NewSyncMap[K comparable, V any]() *SyncMap[K, V] { return &SyncMap[K, V]{} }
+
+// returns the underlying map, initializing it on first use
+func (s *SyncMap[K, V]) inner() *Map[K, V] {
+	s.once.Do(func() { s.m = NewRWMap[K, V](nil) })
+	return &s.m
+}
+
+// Store sets the value for a key
+func (s *SyncMap[K, V]) Store(k K, v V) { s.inner().Insert(k, v) }
+
+// Load returns the value stored in the map for a key, or the zero value if no value is present.
+// The ok result indicates whether value was found in the map.
+func (s *SyncMap[K, V]) Load(k K) (value V, ok bool) { return s.inner().Get(k) }
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+func (s *SyncMap[K, V]) LoadOrStore(k K, v V) (actual V, loaded bool) {
+	return s.inner().LoadOrStore(k, v)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (s *SyncMap[K, V]) LoadAndDelete(k K) (value V, loaded bool) { return s.inner().LoadAndDelete(k) }
+
+// Delete deletes the value for a key
+func (s *SyncMap[K, V]) Delete(k K) { s.inner().Delete(k) }
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+// Range iterates over a snapshot of the map, so f can safely modify the map.
+func (s *SyncMap[K, V]) Range(f func(k K, v V) bool) {
+	for k, v := range s.inner().IterSnapshot() {
+		if !f(k, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestSyncMap_Parity(t *testing.T) {
+	var std sync.Map
+	var typed SyncMap[string, int]
+	std.Store("a", 1)
+	typed.Store("a", 1)
+	stdV, stdOk := std.Load("a")
+	v, ok := typed.Load("a")
+	assert.Equal(t, stdV, v)
+	assert.Equal(t, stdOk, ok)
+
+	stdV, stdLoaded := std.LoadOrStore("a", 2)
+	v, loaded := typed.LoadOrStore("a", 2)
+	assert.Equal(t, stdV, v)
+	assert.Equal(t, stdLoaded, loaded)
+
+	stdV, stdLoaded = std.LoadOrStore("b", 3)
+	v, loaded = typed.LoadOrStore("b", 3)
+	assert.Equal(t, stdV, v)
+	assert.Equal(t, stdLoaded, loaded)
+
+	stdV, stdLoaded = std.LoadAndDelete("b")
+	v, loaded = typed.LoadAndDelete("b")
+	assert.Equal(t, stdV, v)
+	assert.Equal(t, stdLoaded, loaded)
+
+	_, stdLoaded = std.LoadAndDelete("b")
+	_, loaded = typed.LoadAndDelete("b")
+	assert.Equal(t, stdLoaded, loaded)
+
+	std.Delete("a")
+	typed.Delete("a")
+	_, stdOk = std.Load("a")
+	_, ok = typed.Load("a")
+	assert.Equal(t, stdOk, ok)
+}
+
+func TestSyncMap_Range(t *testing.T) {
+	m := NewSyncMap[string, int]()
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+	sum := 0
+	m.Range(func(k string, v int) bool {
+		sum += v
+		m.Delete(k) // the callback can mutate the map
+		return true
+	})
+	assert.Equal(t, 6, sum)
+	count := 0
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Range(func(k string, v int) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
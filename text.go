@@ -0,0 +1,74 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// MarshalText implements encoding.TextMarshaler, encoding the number as its decimal string under the read lock.
+// This makes a Number usable as a JSON object key. A zero Number is encoded as "0".
+func (n Number[T]) MarshalText() ([]byte, error) {
+	return []byte(formatNumber(loadOrZero(n.Locker))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing text produced by MarshalText
+// and storing the number under the write lock. A zero Number is initialized with a sync.Mutex as backend.
+func (n *Number[T]) UnmarshalText(text []byte) error {
+	out, err := parseNumber[T](string(text))
+	if err != nil {
+		return err
+	}
+	if n.Locker == nil {
+		*n = NewNumber(out)
+		return nil
+	}
+	n.Store(out)
+	return nil
+}
+
+// parses any number using strconv, based on its concrete kind
+func parseNumber[T INumber](s string) (out T, err error) {
+	rv := reflect.ValueOf(&out).Elem()
+	bits := rv.Type().Bits()
+	switch {
+	case rv.CanInt():
+		var v int64
+		v, err = strconv.ParseInt(s, 10, bits)
+		rv.SetInt(v)
+	case rv.CanUint():
+		var v uint64
+		v, err = strconv.ParseUint(s, 10, bits)
+		rv.SetUint(v)
+	case rv.CanFloat():
+		var v float64
+		v, err = strconv.ParseFloat(s, bits)
+		rv.SetFloat(v)
+	case rv.CanComplex():
+		var v complex128
+		v, err = strconv.ParseComplex(s, bits)
+		rv.SetComplex(v)
+	}
+	return
+}
@@ -0,0 +1,60 @@
+// MIT License
+//
+// Copyright (c) 2024 Alain Gilbert
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mtx
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNumber_MarshalText(t *testing.T) {
+	data, err := NewNumber(-42).MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "-42", string(data))
+	var n Number[int]
+	assert.NoError(t, n.UnmarshalText(data))
+	assert.Equal(t, -42, n.Load())
+	assert.Error(t, n.UnmarshalText([]byte("abc")))
+	assert.Error(t, NewNumberPtr(int8(0)).UnmarshalText([]byte("300")))
+	data, err = Number[int]{}.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "0", string(data))
+	f := NewRWNumberPtr(0.0)
+	assert.NoError(t, f.UnmarshalText([]byte("1.5")))
+	assert.Equal(t, 1.5, f.Load())
+}
+
+func TestNumber_MarshalText_JSONMapKey(t *testing.T) {
+	in := map[Number[int]]string{NewNumber(1): "a", NewNumber(20): "b"}
+	data, err := json.Marshal(in)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"1":"a","20":"b"}`, string(data))
+	var out map[Number[int]]string
+	assert.NoError(t, json.Unmarshal(data, &out))
+	plain := make(map[int]string)
+	for k, v := range out {
+		plain[k.Load()] = v
+	}
+	assert.Equal(t, map[int]string{1: "a", 20: "b"}, plain)
+}